@@ -0,0 +1,258 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"sort"
+	"strings"
+)
+
+// the AOT (Dialing) parts of speech for the OpenCorpora ones
+var aotPOS = map[string]string{
+	"NOUN": "С",
+	"ADJF": "П",
+	"ADJS": "КР_ПРИЛ",
+	"COMP": "П",
+	"VERB": "Г",
+	"INFN": "ИНФИНИТИВ",
+	"PRTF": "ПРИЧАСТИЕ",
+	"PRTS": "КР_ПРИЧАСТИЕ",
+	"GRND": "ДЕЕПРИЧАСТИЕ",
+	"NUMR": "ЧИСЛ",
+	"ADVB": "Н",
+	"NPRO": "МС",
+	"PRED": "ПРЕДК",
+	"PREP": "ПРЕДЛ",
+	"CONJ": "СОЮЗ",
+	"PRCL": "ЧАСТ",
+	"INTJ": "МЕЖД",
+}
+
+// the OpenCorpora parts of speech and grammemes for the AOT ones
+// missing in aotPOS or ambiguous there
+var aotPOSFrom = map[string][]string{
+	"П":        {"ADJF"},
+	"ЧИСЛ-П":   {"ADJF", "Anum"},
+	"МС-П":     {"ADJF", "Apro"},
+	"МС-ПРЕДК": {"PRED"},
+	"ВВОДН":    {"", "Prnt"},
+}
+
+type aotGrammeme struct {
+	name  string
+	order int
+}
+
+// the AOT grammemes for the OpenCorpora ones, ordered as in the AOT gramtab
+var aotGrammemes = map[string]aotGrammeme{
+	"masc": {"мр", 1},
+	"femn": {"жр", 1},
+	"neut": {"ср", 1},
+	"Ms-f": {"мр-жр", 1},
+	"GNdr": {"мр-жр", 1},
+	"sing": {"ед", 2},
+	"plur": {"мн", 2},
+	"nomn": {"им", 3},
+	"gent": {"рд", 3},
+	"gen1": {"рд", 3},
+	"gen2": {"рд", 3},
+	"datv": {"дт", 3},
+	"accs": {"вн", 3},
+	"acc2": {"вн", 3},
+	"ablt": {"тв", 3},
+	"loct": {"пр", 3},
+	"loc1": {"пр", 3},
+	"loc2": {"пр", 3},
+	"voct": {"зв", 3},
+	"anim": {"од", 5},
+	"inan": {"но", 5},
+	"actv": {"дст", 6},
+	"pssv": {"стр", 6},
+	"pres": {"нст", 7},
+	"past": {"прш", 7},
+	"futr": {"буд", 7},
+	"impr": {"пвл", 8},
+	"1per": {"1л", 9},
+	"2per": {"2л", 9},
+	"3per": {"3л", 9},
+	"perf": {"св", 10},
+	"impf": {"нс", 10},
+	"tran": {"пе", 11},
+	"intr": {"нп", 11},
+	"Fixd": {"0", 12},
+	"Qual": {"кач", 13},
+	"Supr": {"прев", 13},
+	"Name": {"имя", 14},
+	"Surn": {"фам", 14},
+	"Patr": {"отч", 14},
+	"Geox": {"лок", 14},
+	"Orgn": {"орг", 14},
+	"Impe": {"безл", 15},
+	"Poss": {"притяж", 15},
+	"Ques": {"вопр", 15},
+	"Dmns": {"указат", 15},
+	"Infr": {"разг", 16},
+	"Slng": {"жарг", 16},
+	"Arch": {"арх", 16},
+	"Erro": {"опч", 16},
+	"Abbr": {"аббр", 16},
+}
+
+// the OpenCorpora grammemes for the AOT ones; мр-жр and
+// the second cases (рд,2 and пр,2) are handled by FromAOT
+var aotGrammemesFrom = func() map[string]string {
+	m := make(map[string]string)
+	for name, g := range aotGrammemes {
+		if _, ok := m[g.name]; !ok || name < m[g.name] {
+			m[g.name] = name
+		}
+	}
+	for aot, name := range map[string]string{
+		"рд": "gent",
+		"вн": "accs",
+		"пр": "loct",
+	} {
+		m[aot] = name
+	}
+	return m
+}()
+
+// AOT converts the tag to the AOT (Dialing) notation, e.g. "С мр,ед,им"
+// for "NOUN,anim,masc sing,nomn": the part of speech, a space and
+// the comma-separated grammemes. The ordinal numerals (Anum) become ЧИСЛ-П,
+// the adjectival pronouns (Apro) МС-П and the comparatives П сравн.
+// The second genitive and locative become рд,2 and пр,2, the second
+// accusative вн. The indicative mood, the pronoun persons of the adjectives
+// and the other grammemes AOT has no counterpart for are dropped.
+// The tags of the punctuation, numbers and non-Cyrillic words give "".
+func (t Tag) AOT() string {
+	names := splitTag(t.str)
+	if len(names) == 0 {
+		return ""
+	}
+	pos, ok := aotPOS[names[0]]
+	if !ok {
+		return ""
+	}
+	var gs []aotGrammeme
+	add := func(g aotGrammeme) {
+		for _, x := range gs {
+			if x.name == g.name {
+				return
+			}
+		}
+		gs = append(gs, g)
+	}
+	if names[0] == "COMP" {
+		add(aotGrammeme{"сравн", 13})
+	}
+	for _, name := range names[1:] {
+		switch name {
+		case "Anum":
+			if pos == "П" {
+				pos = "ЧИСЛ-П"
+			}
+		case "Apro":
+			if pos == "П" {
+				pos = "МС-П"
+			}
+		case "gen2", "loc2":
+			add(aotGrammemes[name])
+			add(aotGrammeme{"2", 4})
+		default:
+			if g, ok := aotGrammemes[name]; ok {
+				add(g)
+			}
+		}
+	}
+	if len(gs) == 0 {
+		return pos
+	}
+	sort.SliceStable(gs, func(i, j int) bool { return gs[i].order < gs[j].order })
+	var sb strings.Builder
+	sb.WriteString(pos)
+	for i, g := range gs {
+		if i == 0 {
+			sb.WriteByte(' ')
+		} else {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(g.name)
+	}
+	return sb.String()
+}
+
+// FromAOT returns the tags of the loaded dictionary matching the tag in
+// the AOT notation, e.g. "С мр,ед,им", the ones with the fewest grammemes
+// first. As AOT makes fewer distinctions (e.g. it has no indicative mood and
+// no second accusative), a tag may match several OpenCorpora tags.
+// ВВОДН matches the tags with the Prnt grammeme, and мр-жр matches
+// the common gender (GNdr) of the nouns and Ms-f of the other words.
+// The unknown AOT grammemes are ignored; nil is returned for an unknown
+// part of speech or if no tag matches.
+func FromAOT(s string) []Tag {
+	if probDAWG == nil {
+		panic("not initialized; call Init or InitWith")
+	}
+	fields := splitTag(s)
+	if len(fields) == 0 {
+		return nil
+	}
+	var pos string
+	var names []string
+	if p, ok := aotPOSFrom[fields[0]]; ok {
+		pos, names = p[0], append(names, p[1:]...)
+	} else {
+		for oc, aot := range aotPOS {
+			if aot == fields[0] {
+				pos = oc
+			}
+		}
+		if pos == "" {
+			return nil
+		}
+	}
+
+	second := false
+	for _, f := range fields[1:] {
+		second = second || f == "2"
+	}
+	for _, f := range fields[1:] {
+		switch f {
+		case "сравн":
+			if pos == "ADJF" {
+				pos = "COMP"
+			}
+		case "мр-жр":
+			if pos == "NOUN" {
+				names = append(names, "GNdr")
+			} else {
+				names = append(names, "Ms-f")
+			}
+		case "рд", "пр":
+			name := aotGrammemesFrom[f]
+			if second && f == "рд" {
+				name = "gen2"
+			} else if second {
+				name = "loc2"
+			}
+			names = append(names, name)
+		default:
+			if name, ok := aotGrammemesFrom[f]; ok {
+				names = append(names, name)
+			}
+		}
+	}
+	return matchingTags(pos, names)
+}
@@ -0,0 +1,94 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import "testing"
+
+func TestTagAOT(t *testing.T) {
+	tests := []struct {
+		tag, aot string
+	}{
+		{"NOUN,anim,masc sing,nomn", "С мр,ед,им,од"},
+		{"NOUN,inan,masc sing,gen2", "С мр,ед,рд,2,но"},
+		{"NOUN,anim,GNdr,Ms-f,Fixd sing,nomn", "С мр-жр,ед,им,од,0"},
+		{"ADJF,Qual femn,sing,nomn", "П жр,ед,им,кач"},
+		{"ADJS,Qual Ms-f,sing", "КР_ПРИЛ мр-жр,ед,кач"},
+		{"ADJF,Apro,Subx plur,nomn", "МС-П мн,им"},
+		{"ADJF,Anum masc,sing,gent", "ЧИСЛ-П мр,ед,рд"},
+		{"COMP,Qual", "П сравн,кач"},
+		{"VERB,impf,tran sing,3per,pres,indc", "Г ед,нст,3л,нс,пе"},
+		{"VERB,perf,tran plur,impr,excl", "Г мн,пвл,св,пе"},
+		{"PRTS,perf,past,pssv femn,sing", "КР_ПРИЧАСТИЕ жр,ед,стр,прш,св"},
+		{"NPRO,masc,3per,Anph sing,nomn", "МС мр,ед,им,3л"},
+		{"PREP", "ПРЕДЛ"},
+		{"PNCT", ""},
+	}
+	for _, tt := range tests {
+		if aot := LookupTag(tt.tag).AOT(); aot != tt.aot {
+			t.Errorf("LookupTag(%q).AOT(): want %q, got %q", tt.tag, tt.aot, aot)
+		}
+	}
+}
+
+func TestFromAOT(t *testing.T) {
+	needDict(t)
+	for _, tag := range []string{
+		"NOUN,anim,masc sing,nomn",
+		"NOUN,inan,masc sing,gen2",
+		"VERB,impf,tran sing,3per,pres,indc",
+		"ADJF,Qual femn,sing,nomn",
+		"COMP,Qual",
+		"PREP",
+	} {
+		want := LookupTag(tag)
+		got := FromAOT(want.AOT())
+		found := false
+		for _, g := range got {
+			found = found || g.Equal(want)
+		}
+		if !found {
+			t.Errorf("FromAOT(%q): want %s among %v", want.AOT(), tag, got)
+		}
+	}
+	want, err := NewGrammemeSet("NOUN", "masc", "sing", "gent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := FromAOT("С мр,ед,рд")
+	if len(got) == 0 {
+		t.Errorf("FromAOT(С мр,ед,рд): no tags")
+	}
+	for _, g := range got {
+		if !g.HasAll(want) {
+			t.Errorf("FromAOT(С мр,ед,рд): %s does not match", g)
+		}
+	}
+	// мр-жр is Ms-f for the words other than nouns
+	for _, want := range matchingTags("", []string{"Ms-f"}) {
+		if want.set.Has(grammemeIndex["NOUN"]) {
+			continue
+		}
+		found := false
+		for _, g := range FromAOT(want.AOT()) {
+			found = found || g.Equal(want)
+		}
+		if !found {
+			t.Errorf("FromAOT(%q): want %s", want.AOT(), want)
+		}
+		break
+	}
+	if got := FromAOT("НЕТ"); got != nil {
+		t.Errorf("FromAOT(НЕТ): want nil, got %v", got)
+	}
+}
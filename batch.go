@@ -0,0 +1,205 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// BatchOptions configures ParseBatch and ParseStream.
+// A nil *BatchOptions is equivalent to the zero value.
+type BatchOptions struct {
+	// Workers is the number of goroutines doing the analysis.
+	// If it is zero or negative, runtime.GOMAXPROCS(0) is used.
+	Workers int
+
+	// Extended makes the workers use XParse instead of Parse.
+	Extended bool
+}
+
+func (o *BatchOptions) workers() int {
+	if o == nil || o.Workers <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return o.Workers
+}
+
+func (o *BatchOptions) parseFunc() func(string) ([]string, []string, []string) {
+	if o != nil && o.Extended {
+		return XParse
+	}
+	return Parse
+}
+
+// Result holds the analyses of a single word in the same form as they are
+// returned by Parse and XParse.
+type Result struct {
+	Word  string
+	Words []string
+	Norms []string
+	Tags  []string
+}
+
+// ParseBatch analyzes the words using a pool of goroutines and returns
+// the results in the input order. Repeated words are analyzed only once;
+// the results for them share the same slices.
+// If ctx is cancelled before all the words are analyzed,
+// ParseBatch returns ctx.Err().
+func ParseBatch(ctx context.Context, words []string, opts *BatchOptions) ([]Result, error) {
+	if probDAWG == nil {
+		panic("not initialized; call Init or InitWith")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	slots := make([]int, len(words))
+	seen := make(map[string]int, len(words))
+	var uniq []string
+	for i, w := range words {
+		j, ok := seen[w]
+		if !ok {
+			j = len(uniq)
+			seen[w] = j
+			uniq = append(uniq, w)
+		}
+		slots[i] = j
+	}
+
+	parse := opts.parseFunc()
+	results := make([]Result, len(uniq))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	n := opts.workers()
+	if n > len(uniq) {
+		n = len(uniq)
+	}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				words, norms, tags := parse(uniq[j])
+				results[j] = Result{uniq[j], words, norms, tags}
+			}
+		}()
+	}
+
+	var err error
+loop:
+	for j := range uniq {
+		select {
+		case jobs <- j:
+		case <-ctx.Done():
+			err = ctx.Err()
+			break loop
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Result, len(words))
+	for i, j := range slots {
+		out[i] = results[j]
+	}
+	return out, nil
+}
+
+type streamJob struct {
+	word string
+	res  chan<- Result
+}
+
+// ParseStream analyzes the words received from in using a pool of goroutines
+// and sends the results to the returned channel in the input order.
+// The returned channel is closed after in is closed and all the results
+// are sent, or after ctx is cancelled. The caller must either drain
+// the returned channel or cancel ctx.
+func ParseStream(ctx context.Context, in <-chan string, opts *BatchOptions) <-chan Result {
+	if probDAWG == nil {
+		panic("not initialized; call Init or InitWith")
+	}
+
+	parse := opts.parseFunc()
+	n := opts.workers()
+	jobs := make(chan streamJob)
+	pending := make(chan chan Result, n)
+	out := make(chan Result)
+
+	for i := 0; i < n; i++ {
+		go func() {
+			for j := range jobs {
+				words, norms, tags := parse(j.word)
+				j.res <- Result{j.word, words, norms, tags}
+			}
+		}()
+	}
+
+	// dispatcher: keeps the order of the results by queueing
+	// a channel for each word before handing it to a worker
+	go func() {
+		defer close(jobs)
+		defer close(pending)
+		for {
+			var word string
+			select {
+			case w, ok := <-in:
+				if !ok {
+					return
+				}
+				word = w
+			case <-ctx.Done():
+				return
+			}
+
+			res := make(chan Result, 1)
+			select {
+			case pending <- res:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- streamJob{word, res}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer close(out)
+		for res := range pending {
+			var r Result
+			select {
+			case r = <-res:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case out <- r:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
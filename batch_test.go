@@ -0,0 +1,82 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func batchTestWords() []string {
+	var words []string
+	for _, tc := range testCases {
+		words = append(words, tc.word)
+	}
+	for _, tc := range extendedTestCases {
+		words = append(words, tc.word)
+	}
+	return append(words, words...)
+}
+
+func TestParseBatch(t *testing.T) {
+	words := batchTestWords()
+	results, err := ParseBatch(context.Background(), words, &BatchOptions{Workers: 3, Extended: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(words) {
+		t.Fatalf("ParseBatch: want %d results, got %d", len(words), len(results))
+	}
+	for i, r := range results {
+		ws, ns, ts := XParse(words[i])
+		want := Result{words[i], ws, ns, ts}
+		if !reflect.DeepEqual(r, want) {
+			t.Errorf("ParseBatch: result #%d: want %v, got %v", i, want, r)
+		}
+	}
+}
+
+func TestParseBatchCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ParseBatch(ctx, batchTestWords(), nil); err != context.Canceled {
+		t.Errorf("ParseBatch: want %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestParseStream(t *testing.T) {
+	words := batchTestWords()
+	in := make(chan string)
+	go func() {
+		for _, w := range words {
+			in <- w
+		}
+		close(in)
+	}()
+
+	i := 0
+	for r := range ParseStream(context.Background(), in, nil) {
+		if r.Word != words[i] {
+			t.Fatalf("ParseStream: result #%d: want word %q, got %q", i, words[i], r.Word)
+		}
+		if want, _, _ := Parse(words[i]); !reflect.DeepEqual(r.Words, want) {
+			t.Errorf("ParseStream(%q): want words %v, got %v", words[i], want, r.Words)
+		}
+		i++
+	}
+	if i != len(words) {
+		t.Errorf("ParseStream: want %d results, got %d", len(words), i)
+	}
+}
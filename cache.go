@@ -0,0 +1,207 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+)
+
+const maxCacheShards = 16
+
+var (
+	dictCache    *lruCache
+	predictCache *lruCache
+)
+
+// CacheOptions configures the analysis cache.
+type CacheOptions struct {
+	// DictSize is the maximum number of words found in the dictionary
+	// whose analyses (as returned by Parse) are kept in the cache.
+	// Zero disables caching them.
+	DictSize int
+
+	// PredictSize is the maximum number of unknown words whose analyses
+	// (as returned by XParse) are kept in the cache.
+	// Zero disables caching them.
+	PredictSize int
+}
+
+// CacheStats holds the statistics of the analysis cache.
+type CacheStats struct {
+	DictHits      uint64
+	DictMisses    uint64
+	DictLen       int
+	PredictHits   uint64
+	PredictMisses uint64
+	PredictLen    int
+}
+
+// EnableCache enables caching of the analysis results, replacing the
+// previously enabled cache, if any. The cache is safe for concurrent use,
+// but EnableCache and DisableCache themselves must not be called
+// concurrently with the analysis functions.
+func EnableCache(opts CacheOptions) {
+	dictCache = newLRUCache(opts.DictSize)
+	predictCache = newLRUCache(opts.PredictSize)
+}
+
+// DisableCache disables caching of the analysis results and drops the cache.
+func DisableCache() {
+	dictCache = nil
+	predictCache = nil
+}
+
+// ReadCacheStats returns the statistics of the analysis cache.
+func ReadCacheStats() CacheStats {
+	var st CacheStats
+	if c := dictCache; c != nil {
+		st.DictHits = atomic.LoadUint64(&c.hits)
+		st.DictMisses = atomic.LoadUint64(&c.misses)
+		st.DictLen = c.len()
+	}
+	if c := predictCache; c != nil {
+		st.PredictHits = atomic.LoadUint64(&c.hits)
+		st.PredictMisses = atomic.LoadUint64(&c.misses)
+		st.PredictLen = c.len()
+	}
+	return st
+}
+
+type cacheEntry struct {
+	key   string
+	words []string
+	norms []string
+	ids   []int // the indices of the tags in tags
+}
+
+type cacheShard struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type lruCache struct {
+	hits   uint64
+	misses uint64
+	shards []cacheShard
+}
+
+func newLRUCache(size int) *lruCache {
+	if size <= 0 {
+		return nil
+	}
+	n := maxCacheShards
+	if size < n {
+		n = size
+	}
+	c := &lruCache{shards: make([]cacheShard, n)}
+	for i := range c.shards {
+		sh := &c.shards[i]
+		sh.size = size / n
+		if i < size%n {
+			sh.size++
+		}
+		sh.ll = list.New()
+		sh.items = make(map[string]*list.Element)
+	}
+	return c
+}
+
+func (c *lruCache) shard(key string) *cacheShard {
+	// FNV-1a
+	h := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= 16777619
+	}
+	return &c.shards[h%uint32(len(c.shards))]
+}
+
+func copyStrings(ss []string) []string {
+	if ss == nil {
+		return nil
+	}
+	return append([]string(nil), ss...)
+}
+
+func copyInts(is []int) []int {
+	if is == nil {
+		return nil
+	}
+	return append([]int(nil), is...)
+}
+
+// get returns copies of the cached slices, so that the caller is free to modify them.
+func (c *lruCache) get(key string) (words, norms []string, ids []int, ok bool) {
+	sh := c.shard(key)
+	sh.mu.Lock()
+	el, ok := sh.items[key]
+	if !ok {
+		sh.mu.Unlock()
+		atomic.AddUint64(&c.misses, 1)
+		return nil, nil, nil, false
+	}
+	sh.ll.MoveToFront(el)
+	e := el.Value.(*cacheEntry)
+	sh.mu.Unlock()
+	atomic.AddUint64(&c.hits, 1)
+	return copyStrings(e.words), copyStrings(e.norms), copyInts(e.ids), true
+}
+
+// add stores copies of the slices, so that the caller is free to modify them.
+func (c *lruCache) add(key string, words, norms []string, ids []int) {
+	e := &cacheEntry{key, copyStrings(words), copyStrings(norms), copyInts(ids)}
+	sh := c.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if el, ok := sh.items[key]; ok {
+		el.Value = e
+		sh.ll.MoveToFront(el)
+		return
+	}
+	sh.items[key] = sh.ll.PushFront(e)
+	if sh.ll.Len() > sh.size {
+		el := sh.ll.Back()
+		sh.ll.Remove(el)
+		delete(sh.items, el.Value.(*cacheEntry).key)
+	}
+}
+
+func (c *lruCache) len() int {
+	n := 0
+	for i := range c.shards {
+		sh := &c.shards[i]
+		sh.mu.Lock()
+		n += sh.ll.Len()
+		sh.mu.Unlock()
+	}
+	return n
+}
+
+// purge drops all the entries; it is a no-op on a nil cache.
+func (c *lruCache) purge() {
+	if c == nil {
+		return
+	}
+	for i := range c.shards {
+		sh := &c.shards[i]
+		sh.mu.Lock()
+		sh.ll.Init()
+		sh.items = make(map[string]*list.Element)
+		sh.mu.Unlock()
+	}
+}
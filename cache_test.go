@@ -0,0 +1,67 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestLRUCache(t *testing.T) {
+	c := newLRUCache(1)
+	c.add("a", []string{"a"}, []string{"a"}, []int{1})
+	c.add("b", []string{"b"}, []string{"b"}, []int{2})
+	if _, _, _, ok := c.get("a"); ok {
+		t.Error("get(a): want the entry to be evicted")
+	}
+	words, _, ids, ok := c.get("b")
+	if !ok || !reflect.DeepEqual(words, []string{"b"}) || !reflect.DeepEqual(ids, []int{2}) {
+		t.Errorf("get(b): want [b] [2], got %v %v", words, ids)
+	}
+	words[0] = "modified"
+	if words, _, _, _ := c.get("b"); words[0] != "b" {
+		t.Errorf("get(b): the cached entry was modified by the caller")
+	}
+	if c.hits != 2 || c.misses != 1 {
+		t.Errorf("want 2 hits and 1 miss, got %d and %d", c.hits, c.misses)
+	}
+
+	c = newLRUCache(100)
+	for i := 0; i < 1000; i++ {
+		c.add(fmt.Sprint(i), nil, nil, nil)
+	}
+	if n := c.len(); n > 100 {
+		t.Errorf("want at most 100 entries, got %d", n)
+	}
+}
+
+func TestXParseCached(t *testing.T) {
+	EnableCache(CacheOptions{DictSize: 100, PredictSize: 100})
+	defer DisableCache()
+
+	for pass := 0; pass < 2; pass++ {
+		for _, tc := range extendedTestCases {
+			words, norms, tags := XParse(tc.word)
+			if !reflect.DeepEqual([3][]string{words, norms, tags}, tc.want) {
+				t.Errorf("XParse(%q), pass %d: want %v, got %v", tc.word, pass, tc.want, [3][]string{words, norms, tags})
+			}
+		}
+	}
+
+	st := ReadCacheStats()
+	if st.DictHits == 0 || st.PredictHits == 0 {
+		t.Errorf("want both dictionary and prediction cache hits, got %+v", st)
+	}
+}
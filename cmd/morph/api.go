@@ -0,0 +1,350 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/vbatushev/morph"
+)
+
+// the limits of the attested_first completion, which reads all
+// the words with the prefix to order them
+const (
+	minAttestedFirstPrefix = 3
+	maxCompleteScan        = 100000
+)
+
+// the number of the dictionary nodes a pattern search may visit
+const maxPatternNodes = 1000000
+
+// api implements the methods shared by the HTTP and JSON-RPC interfaces.
+type api struct {
+	maxBatch int
+}
+
+// method is an API method; call gets the value returned by newParams
+// with the request decoded into it.
+type method struct {
+	newParams func() interface{}
+	call      func(ctx context.Context, params interface{}) (interface{}, error)
+}
+
+func (a *api) methods() map[string]method {
+	newWords := func() interface{} { return new(wordsRequest) }
+	newForms := func() interface{} { return new(formsRequest) }
+	return map[string]method{
+		"parse":     {newWords, a.parse(false)},
+		"xparse":    {newWords, a.parse(true)},
+		"lemmatize": {newWords, a.lemmatize},
+		"inflect":   {newForms, a.inflect},
+		"lexeme":    {newForms, a.lexeme},
+		"tokenize":  {func() interface{} { return new(textRequest) }, a.tokenize},
+		"complete":  {func() interface{} { return new(completeRequest) }, a.complete},
+		"match":     {func() interface{} { return new(matchRequest) }, a.match},
+	}
+}
+
+// apiError is an error caused by the request.
+type apiError struct {
+	status int // the HTTP status code
+	msg    string
+}
+
+func (e *apiError) Error() string { return e.msg }
+
+func missingParam(name string) error {
+	return &apiError{http.StatusBadRequest, "missing " + name}
+}
+
+type wordsRequest struct {
+	Words []string `json:"words"`
+}
+
+type formRequest struct {
+	Word      string   `json:"word"`
+	Tag       string   `json:"tag,omitempty"`
+	Grammemes []string `json:"grammemes,omitempty"`
+}
+
+type formsRequest struct {
+	Requests []formRequest `json:"requests"`
+}
+
+type inflectResult struct {
+	Word  string `json:"word"`
+	Form  string `json:"form,omitempty"`
+	Tag   string `json:"tag,omitempty"`
+	Found bool   `json:"found"`
+}
+
+type parseResult struct {
+	Word     string     `json:"word"`
+	Analyses []analysis `json:"analyses"`
+}
+
+type lemmatizeResult struct {
+	Word   string   `json:"word"`
+	Lemmas []string `json:"lemmas"`
+}
+
+type lexemeResult struct {
+	Word  string     `json:"word"`
+	Forms []analysis `json:"forms"`
+}
+
+type textRequest struct {
+	Text    string `json:"text"`
+	Analyze bool   `json:"analyze,omitempty"`
+}
+
+type tokenResult struct {
+	Text      string     `json:"text"`
+	Kind      string     `json:"kind"`
+	Start     int        `json:"start"`
+	End       int        `json:"end"`
+	RuneStart int        `json:"rune_start"`
+	RuneEnd   int        `json:"rune_end"`
+	Analyses  []analysis `json:"analyses,omitempty"`
+}
+
+type completeRequest struct {
+	Prefix        string `json:"prefix"`
+	Limit         int    `json:"limit,omitempty"`
+	AttestedFirst bool   `json:"attested_first,omitempty"`
+}
+
+type matchRequest struct {
+	Pattern    string   `json:"pattern"`
+	MinLen     int      `json:"min_len,omitempty"`
+	MaxLen     int      `json:"max_len,omitempty"`
+	Limit      int      `json:"limit,omitempty"`
+	Grammemes  []string `json:"grammemes,omitempty"`
+	Exclude    []string `json:"exclude,omitempty"`
+	LemmasOnly bool     `json:"lemmas_only,omitempty"`
+}
+
+type completeResult struct {
+	Word     string     `json:"word"`
+	Attested bool       `json:"attested"`
+	Analyses []analysis `json:"analyses"`
+}
+
+func (a *api) checkBatch(n int) error {
+	if n > a.maxBatch {
+		return &apiError{http.StatusRequestEntityTooLarge, fmt.Sprintf("too many words: %d > %d", n, a.maxBatch)}
+	}
+	return nil
+}
+
+func (a *api) parseWords(ctx context.Context, req interface{}, extended bool) ([]morph.Result, error) {
+	words := req.(*wordsRequest).Words
+	if words == nil {
+		return nil, missingParam("words")
+	}
+	if err := a.checkBatch(len(words)); err != nil {
+		return nil, err
+	}
+	lower := make([]string, len(words))
+	for i, w := range words {
+		lower[i] = strings.ToLower(w)
+	}
+	results, err := morph.ParseBatch(ctx, lower, &morph.BatchOptions{Workers: 1, Extended: extended})
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Word = words[i]
+	}
+	return results, nil
+}
+
+func (a *api) parse(extended bool) func(context.Context, interface{}) (interface{}, error) {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		results, err := a.parseWords(ctx, req, extended)
+		if err != nil {
+			return nil, err
+		}
+		recs := make([]parseResult, len(results))
+		for i, r := range results {
+			recs[i].Word = r.Word
+			recs[i].Analyses = []analysis{}
+			for j := range r.Words {
+				recs[i].Analyses = append(recs[i].Analyses, analysis{r.Words[j], r.Norms[j], r.Tags[j]})
+			}
+		}
+		return recs, nil
+	}
+}
+
+func (a *api) lemmatize(ctx context.Context, req interface{}) (interface{}, error) {
+	results, err := a.parseWords(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+	recs := make([]lemmatizeResult, len(results))
+	for i, r := range results {
+		recs[i].Word = r.Word
+		recs[i].Lemmas = []string{}
+		for _, norm := range r.Norms {
+			if !contains(recs[i].Lemmas, norm) {
+				recs[i].Lemmas = append(recs[i].Lemmas, norm)
+			}
+		}
+	}
+	return recs, nil
+}
+
+func (a *api) inflect(ctx context.Context, req interface{}) (interface{}, error) {
+	reqs := req.(*formsRequest).Requests
+	if reqs == nil {
+		return nil, missingParam("requests")
+	}
+	if err := a.checkBatch(len(reqs)); err != nil {
+		return nil, err
+	}
+	results := make([]inflectResult, len(reqs))
+	for i, r := range reqs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		results[i].Word = r.Word
+		results[i].Form, results[i].Tag, results[i].Found = morph.Inflect(strings.ToLower(r.Word), r.Tag, r.Grammemes...)
+	}
+	return results, nil
+}
+
+func (a *api) lexeme(ctx context.Context, req interface{}) (interface{}, error) {
+	reqs := req.(*formsRequest).Requests
+	if reqs == nil {
+		return nil, missingParam("requests")
+	}
+	if err := a.checkBatch(len(reqs)); err != nil {
+		return nil, err
+	}
+	results := make([]lexemeResult, len(reqs))
+	for i, r := range reqs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		results[i].Word = r.Word
+		results[i].Forms = []analysis{}
+		words, norms, tags := morph.Lexeme(strings.ToLower(r.Word), r.Tag)
+		for j := range words {
+			results[i].Forms = append(results[i].Forms, analysis{words[j], norms[j], tags[j]})
+		}
+	}
+	return results, nil
+}
+
+func (a *api) complete(ctx context.Context, req interface{}) (interface{}, error) {
+	r := req.(*completeRequest)
+	if r.Prefix == "" {
+		return nil, missingParam("prefix")
+	}
+	limit := r.Limit
+	if limit <= 0 || limit > a.maxBatch {
+		limit = a.maxBatch
+	}
+	prefix := strings.ToLower(r.Prefix)
+	var cs []morph.Completion
+	if r.AttestedFirst {
+		if utf8.RuneCountInString(prefix) < minAttestedFirstPrefix {
+			return nil, &apiError{http.StatusBadRequest, fmt.Sprintf("attested_first needs a prefix of at least %d letters", minAttestedFirstPrefix)}
+		}
+		var err error
+		if cs, err = morph.CompleteAttestedFirst(ctx, prefix, limit, maxCompleteScan); err != nil {
+			return nil, err
+		}
+	} else {
+		cs = morph.Complete(prefix, limit)
+	}
+	results := make([]completeResult, len(cs))
+	for i, c := range cs {
+		results[i] = completeResult{c.Word, c.Attested, []analysis{}}
+		for j := range c.Norms {
+			results[i].Analyses = append(results[i].Analyses, analysis{c.Word, c.Norms[j], c.Tags[j]})
+		}
+	}
+	return results, nil
+}
+
+func (a *api) match(ctx context.Context, req interface{}) (interface{}, error) {
+	r := req.(*matchRequest)
+	if r.Pattern == "" {
+		return nil, missingParam("pattern")
+	}
+	limit := r.Limit
+	if limit <= 0 || limit > a.maxBatch {
+		limit = a.maxBatch
+	}
+	if strings.HasPrefix(r.Pattern, "*") && r.MaxLen <= 0 {
+		return nil, &apiError{http.StatusBadRequest, "a pattern starting with * needs max_len"}
+	}
+	entries, err := morph.MatchPattern(ctx, morph.PatternQuery{
+		Pattern:  r.Pattern,
+		MinLen:   r.MinLen,
+		MaxLen:   r.MaxLen,
+		Limit:    limit,
+		MaxNodes: maxPatternNodes,
+		Filter: morph.WordFilter{
+			Grammemes:  r.Grammemes,
+			Exclude:    r.Exclude,
+			LemmasOnly: r.LemmasOnly,
+		},
+	})
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return nil, err
+	}
+	if err != nil {
+		return nil, &apiError{http.StatusBadRequest, err.Error()}
+	}
+	results := make([]analysis, len(entries))
+	for i, e := range entries {
+		results[i] = analysis{e.Word, e.Norm, e.Tag}
+	}
+	return results, nil
+}
+
+func (a *api) tokenize(ctx context.Context, req interface{}) (interface{}, error) {
+	r := req.(*textRequest)
+	if r.Text == "" {
+		return nil, missingParam("text")
+	}
+	if len(r.Text) > maxRequestBody {
+		return nil, &apiError{http.StatusRequestEntityTooLarge, "text too long"}
+	}
+	var results []tokenResult
+	if r.Analyze {
+		for _, tok := range morph.Analyze(r.Text) {
+			res := tokenResult{tok.Text, tok.Kind.String(), tok.Start, tok.End, tok.RuneStart, tok.RuneEnd, nil}
+			for j := range tok.Words {
+				res.Analyses = append(res.Analyses, analysis{tok.Words[j], tok.Norms[j], tok.Tags[j]})
+			}
+			results = append(results, res)
+		}
+	} else {
+		for _, tok := range morph.Tokenize(r.Text) {
+			results = append(results, tokenResult{tok.Text, tok.Kind.String(), tok.Start, tok.End, tok.RuneStart, tok.RuneEnd, nil})
+		}
+	}
+	if results == nil {
+		results = []tokenResult{}
+	}
+	return results, nil
+}
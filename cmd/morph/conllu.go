@@ -0,0 +1,106 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/vbatushev/morph"
+)
+
+func conllu(args []string) error {
+	fs := flag.NewFlagSet("conllu", flag.ExitOnError)
+	dict := fs.String("dict", "", "pymorphy2 dictionary `directory` (found using python if empty)")
+	hmm := fs.String("hmm", "", "HMM model `file` to disambiguate the words in context")
+	eval := fs.Bool("eval", false, "evaluate the annotation against the input instead of annotating it")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: morph conllu [flags] [file...]\n\n")
+		fmt.Fprintf(os.Stderr, "Conllu fills in the empty LEMMA, UPOS, XPOS and FEATS columns of the\n")
+		fmt.Fprintf(os.Stderr, "CoNLL-U files (or the standard input) and writes the result to the\n")
+		fmt.Fprintf(os.Stderr, "standard output. With -eval, it prints the accuracy of the annotation\n")
+		fmt.Fprintf(os.Stderr, "against the input files instead.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if err := initDict(*dict); err != nil {
+		return err
+	}
+	var a morph.Annotator
+	if *hmm != "" {
+		m, err := morph.LoadHMM(*hmm)
+		if err != nil {
+			return err
+		}
+		a.HMM = m
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	var e morph.Evaluation
+	process := func(r io.Reader) error {
+		cr := morph.NewCoNLLUReader(r)
+		for {
+			s, err := cr.Read()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if *eval {
+				a.Evaluate(s, &e)
+				continue
+			}
+			a.Annotate(s)
+			if err := morph.WriteCoNLLU(w, s); err != nil {
+				return err
+			}
+		}
+	}
+
+	if fs.NArg() == 0 {
+		if err := process(os.Stdin); err != nil {
+			return err
+		}
+	}
+	for _, fn := range fs.Args() {
+		f, err := os.Open(fn)
+		if err != nil {
+			return err
+		}
+		err = process(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", fn, err)
+		}
+	}
+
+	if *eval {
+		percent := func(n int) float64 {
+			if e.Words == 0 {
+				return 0
+			}
+			return 100 * float64(n) / float64(e.Words)
+		}
+		fmt.Fprintf(w, "words\t%d\n", e.Words)
+		fmt.Fprintf(w, "UPOS\t%.2f%%\n", percent(e.UPOS))
+		fmt.Fprintf(w, "FEATS\t%.2f%%\n", percent(e.Feats))
+		fmt.Fprintf(w, "LEMMA\t%.2f%%\n", percent(e.Lemmas))
+	}
+	return w.Flush()
+}
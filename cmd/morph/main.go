@@ -0,0 +1,91 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Command morph is a command-line interface to the morph package.
+//
+// Usage:
+//
+//	morph <command> [arguments]
+//
+// The commands are:
+//
+//	conllu       annotate CoNLL-U files
+//	mystem       analyze text like Yandex Mystem
+//	parse        print the analyses of the words of a text
+//	rpc          serve JSON-RPC over the standard input and output
+//	serve        serve the HTTP JSON API
+//	train-hmm    train the HMM disambiguating the words in context
+//	train-probs  estimate P(tag|word) from an annotated corpus
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/vbatushev/morph"
+)
+
+type command struct {
+	run  func(args []string) error
+	help string
+}
+
+var commands = map[string]command{
+	"conllu":      {conllu, "annotate CoNLL-U files"},
+	"mystem":      {mystem, "analyze text like Yandex Mystem"},
+	"parse":       {parse, "print the analyses of the words of a text"},
+	"rpc":         {rpc, "serve JSON-RPC over the standard input and output"},
+	"serve":       {serve, "serve the HTTP JSON API"},
+	"train-hmm":   {trainHMM, "train the HMM disambiguating the words in context"},
+	"train-probs": {trainProbs, "estimate P(tag|word) from an annotated corpus"},
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: morph <command> [arguments]\n\nThe commands are:\n\n")
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "\t%-12s %s\n", name, commands[name].help)
+	}
+	os.Exit(2)
+}
+
+// initDict loads the dictionary from dir or, if it is empty,
+// from the installed pymorphy2 dictionary package.
+func initDict(dir string) error {
+	if dir == "" {
+		return morph.Init()
+	}
+	return morph.InitWith(dir)
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("morph: ")
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,31 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/vbatushev/morph"
+)
+
+var dictLoaded bool
+
+func init() { dictLoaded = morph.Init() == nil }
+
+// needDict skips the test if the dictionary could not be loaded.
+func needDict(t testing.TB) {
+	if !dictLoaded {
+		t.Skip("the dictionary is not initialized")
+	}
+}
@@ -0,0 +1,336 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/vbatushev/morph"
+)
+
+// mystemAnalysis is an analysis in the Mystem JSON output.
+type mystemAnalysis struct {
+	Lex  string `json:"lex"`
+	Gr   string `json:"gr,omitempty"`
+	Qual string `json:"qual,omitempty"`
+}
+
+// mystemItem is a word (with the analyses) or the text between
+// the words in the Mystem JSON output.
+type mystemItem struct {
+	Analysis *[]mystemAnalysis `json:"analysis,omitempty"`
+	Text     string            `json:"text,omitempty"`
+}
+
+type mystemOptions struct {
+	copy    bool // -c
+	newline bool // -n
+	lemmas  bool // -l
+	info    bool // -i
+	glue    bool // -g
+	disamb  bool // -d
+	known   bool // -w
+	json    bool
+	hmm     *morph.HMM // chooses the analyses in context with -d
+}
+
+// analyses returns the Mystem analyses of the word. The norms and tags
+// are its analyses chosen by the HMM, or nil to analyze it with XParse.
+func (o *mystemOptions) analyses(word string, norms, tags []string) []mystemAnalysis {
+	lower := strings.ToLower(word)
+	qual := ""
+	if !morph.IsKnown(lower) {
+		if o.known {
+			return nil
+		}
+		qual = "bastard"
+	}
+	if norms == nil {
+		_, norms, tags = morph.XParse(lower)
+	}
+
+	as := make([]mystemAnalysis, 0, len(norms))
+	for i, norm := range norms {
+		gr := morph.LookupTag(tags[i]).Mystem()
+		if gr == "" {
+			continue
+		}
+		if !o.info {
+			gr = ""
+		}
+		a := mystemAnalysis{norm, gr, qual}
+		found := false
+		for _, b := range as {
+			found = found || a == b
+		}
+		if !found {
+			as = append(as, a)
+		}
+		if o.disamb {
+			break
+		}
+	}
+	if o.glue {
+		as = glueMystem(as)
+	}
+	return as
+}
+
+// glueMystem joins the analyses with the same lemma and lexical grammemes,
+// e.g. "сталь=S,жен,неод=(вин,мн|род,ед)", as Mystem does with -g.
+func glueMystem(as []mystemAnalysis) []mystemAnalysis {
+	var glued []mystemAnalysis
+	var infl [][]string
+	for _, a := range as {
+		lex, form := a.Gr, ""
+		if i := strings.IndexByte(a.Gr, '='); i >= 0 {
+			lex, form = a.Gr[:i], a.Gr[i+1:]
+		}
+		found := false
+		for i, b := range glued {
+			if b.Lex == a.Lex && b.Gr == lex && b.Qual == a.Qual {
+				infl[i] = append(infl[i], form)
+				found = true
+				break
+			}
+		}
+		if !found {
+			glued = append(glued, mystemAnalysis{a.Lex, lex, a.Qual})
+			infl = append(infl, []string{form})
+		}
+	}
+	for i := range glued {
+		if glued[i].Gr == "" {
+			continue
+		}
+		if len(infl[i]) == 1 {
+			glued[i].Gr += "=" + infl[i][0]
+		} else {
+			glued[i].Gr += "=(" + strings.Join(infl[i], "|") + ")"
+		}
+	}
+	return glued
+}
+
+// writeText writes the word in the Mystem text format, e.g. "мама{мама=S,жен,од=им,ед}".
+func (o *mystemOptions) writeText(w *bufio.Writer, word string, as []mystemAnalysis) {
+	if !o.lemmas {
+		w.WriteString(word)
+	}
+	w.WriteByte('{')
+	if len(as) == 0 {
+		w.WriteString(strings.ToLower(word))
+		w.WriteString("??")
+	}
+	for i, a := range as {
+		if i > 0 {
+			w.WriteByte('|')
+		}
+		w.WriteString(a.Lex)
+		if a.Qual != "" {
+			w.WriteByte('?')
+		}
+		if a.Gr != "" {
+			w.WriteByte('=')
+			w.WriteString(a.Gr)
+		}
+	}
+	w.WriteByte('}')
+	if o.newline {
+		w.WriteByte('\n')
+	}
+}
+
+func (o *mystemOptions) processLine(w *bufio.Writer, line string) error {
+	var items []mystemItem
+	last := 0
+	between := func(end int) {
+		if o.copy && end > last {
+			if o.json {
+				items = append(items, mystemItem{Text: line[last:end]})
+			} else {
+				w.WriteString(line[last:end])
+			}
+		}
+	}
+	toks := morph.Tokenize(line)
+	var norms, tags []string
+	if o.disamb && o.hmm != nil {
+		// the line is taken as a sentence
+		texts := make([]string, len(toks))
+		for i, tok := range toks {
+			texts[i] = tok.Text
+		}
+		_, norms, tags = o.hmm.Disambiguate(texts)
+	}
+	for i, tok := range toks {
+		if tok.Kind != morph.TokenWord && tok.Kind != morph.TokenLatin {
+			continue
+		}
+		var as []mystemAnalysis
+		if tok.Kind == morph.TokenWord {
+			if norms != nil {
+				as = o.analyses(tok.Text, norms[i:i+1], tags[i:i+1])
+			} else {
+				as = o.analyses(tok.Text, nil, nil)
+			}
+			if as == nil && o.known {
+				continue
+			}
+		} else if o.known {
+			continue
+		}
+		between(tok.Start)
+		last = tok.End
+		if o.json {
+			if as == nil {
+				as = []mystemAnalysis{}
+			}
+			item := mystemItem{Analysis: &as}
+			if !o.lemmas {
+				item.Text = tok.Text
+			}
+			items = append(items, item)
+		} else {
+			o.writeText(w, tok.Text, as)
+		}
+	}
+	between(len(line))
+
+	if o.json {
+		if o.copy {
+			items = append(items, mystemItem{Text: "\n"})
+		}
+		if items == nil {
+			items = []mystemItem{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		return enc.Encode(items)
+	}
+	if o.copy || !o.newline {
+		w.WriteByte('\n')
+	}
+	return nil
+}
+
+func (o *mystemOptions) process(r io.Reader, w *bufio.Writer) error {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1<<20)
+	for s.Scan() {
+		if err := o.processLine(w, s.Text()); err != nil {
+			return err
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
+
+// splitMystemFlags splits the combined single-letter flags, e.g. -cgin,
+// into separate ones, as Mystem accepts them.
+func splitMystemFlags(args []string) []string {
+	var out []string
+	for i, arg := range args {
+		if arg == "--" {
+			return append(out, args[i:]...)
+		}
+		if len(arg) > 2 && arg[0] == '-' && strings.Trim(arg[1:], "cnligdw") == "" {
+			for _, c := range arg[1:] {
+				out = append(out, "-"+string(c))
+			}
+			continue
+		}
+		out = append(out, arg)
+	}
+	return out
+}
+
+func mystem(args []string) error {
+	fs := flag.NewFlagSet("mystem", flag.ExitOnError)
+	dict := fs.String("dict", "", "pymorphy2 dictionary `directory` (found using python if empty)")
+	format := fs.String("format", "text", "output `format`: text or json")
+	var o mystemOptions
+	fs.BoolVar(&o.copy, "c", false, "copy the whole input to the output, not only the words")
+	fs.BoolVar(&o.newline, "n", false, "print every word on a new line")
+	fs.BoolVar(&o.lemmas, "l", false, "do not print the source words, only the lemmas and the grammemes")
+	fs.BoolVar(&o.info, "i", false, "print the grammatical information")
+	fs.BoolVar(&o.glue, "g", false, "glue the grammatical information of the forms of a lemma (with -i)")
+	fs.BoolVar(&o.disamb, "d", false, "print only the most probable analysis of each word, regardless of the context unless -hmm is given")
+	hmm := fs.String("hmm", "", "HMM model `file` to choose the analysis of each word in context with -d")
+	fs.BoolVar(&o.known, "w", false, "print only the dictionary words")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: morph mystem [flags] [input [output]]\n\n")
+		fmt.Fprintf(os.Stderr, "Mystem analyzes the text like Yandex Mystem does and prints the result\n")
+		fmt.Fprintf(os.Stderr, "in its text or JSON format, e.g. мама{мама=S,жен,од=им,ед} with -i.\n")
+		fmt.Fprintf(os.Stderr, "The input and the output default to the standard ones; the single-letter\n")
+		fmt.Fprintf(os.Stderr, "flags may be combined, e.g. -cgin.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(splitMystemFlags(args))
+	switch *format {
+	case "text":
+	case "json":
+		o.json = true
+	default:
+		return fmt.Errorf("unknown output format: %s", *format)
+	}
+	if fs.NArg() > 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if err := initDict(*dict); err != nil {
+		return err
+	}
+	if *hmm != "" {
+		m, err := morph.LoadHMM(*hmm)
+		if err != nil {
+			return err
+		}
+		o.hmm = m
+	}
+
+	r := io.Reader(os.Stdin)
+	if fs.NArg() > 0 && fs.Arg(0) != "-" {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	out := os.Stdout
+	if fs.NArg() > 1 && fs.Arg(1) != "-" {
+		f, err := os.Create(fs.Arg(1))
+		if err != nil {
+			return err
+		}
+		out = f
+	}
+	err := o.process(r, bufio.NewWriter(out))
+	if out != os.Stdout {
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
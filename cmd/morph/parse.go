@@ -0,0 +1,274 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/vbatushev/morph"
+)
+
+type analysis struct {
+	Word string `json:"word"`
+	Norm string `json:"norm"`
+	Tag  string `json:"tag"`
+}
+
+type record struct {
+	Token    string     `json:"token"`
+	Kind     string     `json:"kind,omitempty"`
+	Analyses []analysis `json:"analyses,omitempty"`
+	Lemmas   []string   `json:"lemmas,omitempty"`
+}
+
+// formatter writes the records. endLine is called after each input line,
+// so that the streaming formats show the results at once; flush is called
+// at the end of the input.
+type formatter interface {
+	write(r *record) error
+	endLine() error
+	flush() error
+}
+
+type tsvFormatter struct{ w *bufio.Writer }
+
+func (f tsvFormatter) write(r *record) error {
+	switch {
+	case len(r.Lemmas) > 0:
+		for _, lemma := range r.Lemmas {
+			fmt.Fprintf(f.w, "%s\t%s\n", r.Token, lemma)
+		}
+	case len(r.Analyses) > 0:
+		for _, a := range r.Analyses {
+			fmt.Fprintf(f.w, "%s\t%s\t%s\t%s\n", r.Token, a.Word, a.Norm, a.Tag)
+		}
+	default:
+		fmt.Fprintf(f.w, "%s\n", r.Token)
+	}
+	return nil
+}
+
+func (f tsvFormatter) endLine() error { return f.w.Flush() }
+func (f tsvFormatter) flush() error   { return f.w.Flush() }
+
+type jsonlFormatter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (f jsonlFormatter) write(r *record) error { return f.enc.Encode(r) }
+func (f jsonlFormatter) endLine() error        { return f.w.Flush() }
+func (f jsonlFormatter) flush() error          { return f.w.Flush() }
+
+type tableFormatter struct {
+	w      *bufio.Writer
+	tw     *tabwriter.Writer
+	lemmas bool
+	header bool
+}
+
+func (f *tableFormatter) write(r *record) error {
+	if !f.header {
+		if f.lemmas {
+			fmt.Fprintln(f.tw, "TOKEN\tLEMMA")
+		} else {
+			fmt.Fprintln(f.tw, "TOKEN\tWORD\tNORM\tTAG")
+		}
+		f.header = true
+	}
+	token := r.Token
+	switch {
+	case f.lemmas:
+		if len(r.Lemmas) == 0 {
+			fmt.Fprintf(f.tw, "%s\t-\n", token)
+		}
+		for _, lemma := range r.Lemmas {
+			fmt.Fprintf(f.tw, "%s\t%s\n", token, lemma)
+			token = ""
+		}
+	case len(r.Analyses) == 0:
+		fmt.Fprintf(f.tw, "%s\t-\t-\t-\n", token)
+	default:
+		for _, a := range r.Analyses {
+			fmt.Fprintf(f.tw, "%s\t%s\t%s\t%s\n", token, a.Word, a.Norm, a.Tag)
+			token = ""
+		}
+	}
+	return nil
+}
+
+// endLine does nothing: the table is aligned as a whole at the end.
+func (f *tableFormatter) endLine() error { return nil }
+
+func (f *tableFormatter) flush() error {
+	if err := f.tw.Flush(); err != nil {
+		return err
+	}
+	return f.w.Flush()
+}
+
+func newFormatter(w io.Writer, format string, lemmas bool) (formatter, error) {
+	bw := bufio.NewWriter(w)
+	switch format {
+	case "tsv":
+		return tsvFormatter{bw}, nil
+	case "jsonl":
+		enc := json.NewEncoder(bw)
+		enc.SetEscapeHTML(false)
+		return jsonlFormatter{bw, enc}, nil
+	case "table":
+		return &tableFormatter{w: bw, tw: tabwriter.NewWriter(bw, 0, 8, 2, ' ', 0), lemmas: lemmas}, nil
+	}
+	return nil, fmt.Errorf("unknown output format: %s", format)
+}
+
+type parseOptions struct {
+	lines  bool
+	known  bool
+	lemmas bool
+	top    bool
+	ext    bool
+}
+
+func (o *parseOptions) record(token, word string) *record {
+	var words, norms, tags []string
+	if o.known {
+		words, norms, tags = morph.Parse(word)
+	} else {
+		words, norms, tags = morph.XParse(word)
+	}
+	if o.top && len(words) > 1 {
+		words, norms, tags = words[:1], norms[:1], tags[:1]
+	}
+
+	r := &record{Token: token}
+	if o.lemmas {
+		for _, norm := range norms {
+			if !contains(r.Lemmas, norm) {
+				r.Lemmas = append(r.Lemmas, norm)
+			}
+		}
+		return r
+	}
+	for i := range words {
+		tag := tags[i]
+		if o.ext {
+			tag = morph.ExternalTag(tag)
+		}
+		r.Analyses = append(r.Analyses, analysis{words[i], norms[i], tag})
+	}
+	return r
+}
+
+func contains(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *parseOptions) process(r io.Reader, f formatter) error {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1<<20)
+	for s.Scan() {
+		line := s.Text()
+		if o.lines {
+			token := strings.TrimSpace(line)
+			if token == "" {
+				continue
+			}
+			if err := f.write(o.record(token, morph.NormalizeWord(token))); err != nil {
+				return err
+			}
+		} else {
+			for _, tok := range morph.Tokenize(line) {
+				var rec *record
+				if tok.Kind == morph.TokenWord {
+					rec = o.record(tok.Text, morph.NormalizeWord(tok.Text))
+				} else {
+					rec = &record{Token: tok.Text}
+				}
+				rec.Kind = tok.Kind.String()
+				if err := f.write(rec); err != nil {
+					return err
+				}
+			}
+		}
+		if err := f.endLine(); err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
+
+func parse(args []string) error {
+	fs := flag.NewFlagSet("parse", flag.ExitOnError)
+	dict := fs.String("dict", "", "pymorphy2 dictionary `directory` (found using python if empty)")
+	format := fs.String("format", "tsv", "output `format`: tsv, jsonl or table")
+	var o parseOptions
+	fs.BoolVar(&o.lines, "lines", false, "read one word per line instead of text")
+	fs.BoolVar(&o.known, "known", false, "analyze only the words found in the dictionary (Parse instead of XParse)")
+	fs.BoolVar(&o.lemmas, "lemmas", false, "print only the lemmas")
+	fs.BoolVar(&o.top, "top", false, "print only the most probable analysis")
+	fs.BoolVar(&o.ext, "ext", false, "print the tags in the external format with Cyrillic grammemes")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: morph parse [flags] [file...]\n\n")
+		fmt.Fprintf(os.Stderr, "Parse reads text (or words, one per line, with -lines) from the files\n")
+		fmt.Fprintf(os.Stderr, "or the standard input and prints the analyses of the words.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	f, err := newFormatter(os.Stdout, *format, o.lemmas)
+	if err != nil {
+		return err
+	}
+	if err := initDict(*dict); err != nil {
+		return err
+	}
+
+	err = o.processFiles(fs.Args(), f)
+	if ferr := f.flush(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// processFiles processes the files or, if there are none, the standard input.
+func (o *parseOptions) processFiles(files []string, f formatter) error {
+	if len(files) == 0 {
+		return o.process(os.Stdin, f)
+	}
+	for _, fn := range files {
+		r, err := os.Open(fn)
+		if err != nil {
+			return err
+		}
+		err = o.process(r, f)
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", fn, err)
+		}
+	}
+	return nil
+}
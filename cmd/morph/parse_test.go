@@ -0,0 +1,120 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+var formatterRecords = []*record{
+	{Token: "Стали", Kind: "word", Analyses: []analysis{
+		{"стали", "сталь", "NOUN,inan,femn sing,gent"},
+		{"стали", "стать", "VERB,perf,intr plur,past,indc"},
+	}},
+	{Token: ",", Kind: "punct"},
+	{Token: "ёжик", Kind: "word", Analyses: []analysis{
+		{"ёжик", "ёжик", "NOUN,anim,masc sing,nomn"},
+	}},
+}
+
+var lemmaRecords = []*record{
+	{Token: "Стали", Kind: "word", Lemmas: []string{"сталь", "стать"}},
+	{Token: ",", Kind: "punct"},
+	{Token: "ёжик", Kind: "word", Lemmas: []string{"ёжик"}},
+}
+
+func TestFormatters(t *testing.T) {
+	tests := []struct {
+		format  string
+		lemmas  bool
+		records []*record
+		want    string
+	}{
+		{"tsv", false, formatterRecords, "" +
+			"Стали\tстали\tсталь\tNOUN,inan,femn sing,gent\n" +
+			"Стали\tстали\tстать\tVERB,perf,intr plur,past,indc\n" +
+			",\n" +
+			"ёжик\tёжик\tёжик\tNOUN,anim,masc sing,nomn\n"},
+		{"tsv", true, lemmaRecords, "" +
+			"Стали\tсталь\n" +
+			"Стали\tстать\n" +
+			",\n" +
+			"ёжик\tёжик\n"},
+		{"jsonl", false, formatterRecords, "" +
+			`{"token":"Стали","kind":"word","analyses":[{"word":"стали","norm":"сталь","tag":"NOUN,inan,femn sing,gent"},{"word":"стали","norm":"стать","tag":"VERB,perf,intr plur,past,indc"}]}` + "\n" +
+			`{"token":",","kind":"punct"}` + "\n" +
+			`{"token":"ёжик","kind":"word","analyses":[{"word":"ёжик","norm":"ёжик","tag":"NOUN,anim,masc sing,nomn"}]}` + "\n"},
+		{"table", false, formatterRecords, "" +
+			"TOKEN  WORD   NORM   TAG\n" +
+			"Стали  стали  сталь  NOUN,inan,femn sing,gent\n" +
+			"       стали  стать  VERB,perf,intr plur,past,indc\n" +
+			",      -      -      -\n" +
+			"ёжик   ёжик   ёжик   NOUN,anim,masc sing,nomn\n"},
+		{"table", true, lemmaRecords, "" +
+			"TOKEN  LEMMA\n" +
+			"Стали  сталь\n" +
+			"       стать\n" +
+			",      -\n" +
+			"ёжик   ёжик\n"},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		f, err := newFormatter(&buf, test.format, test.lemmas)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range test.records {
+			if err := f.write(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := f.flush(); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("%s (lemmas %v): want\n%s\ngot\n%s", test.format, test.lemmas, test.want, got)
+		}
+	}
+
+	if _, err := newFormatter(&bytes.Buffer{}, "xml", false); err == nil {
+		t.Error("newFormatter: want an error for an unknown format")
+	}
+}
+
+func TestFormatterEndLine(t *testing.T) {
+	for _, tt := range []struct {
+		format string
+		shown  bool // the record is written out at the end of the line
+	}{
+		{"tsv", true},
+		{"jsonl", true},
+		{"table", false},
+	} {
+		var buf bytes.Buffer
+		f, err := newFormatter(&buf, tt.format, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := f.write(formatterRecords[0]); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.endLine(); err != nil {
+			t.Fatal(err)
+		}
+		if shown := buf.Len() > 0; shown != tt.shown {
+			t.Errorf("%s: want the output shown at the end of the line: %v, got %v", tt.format, tt.shown, shown)
+		}
+	}
+}
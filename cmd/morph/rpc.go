@@ -0,0 +1,190 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// the JSON-RPC 2.0 error codes
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+var nullID = json.RawMessage("null")
+
+// rpcServer reads the requests, one per line, and writes the responses,
+// one per line, in the order they are ready.
+type rpcServer struct {
+	methods map[string]method
+	sem     chan struct{}
+	wg      sync.WaitGroup
+
+	mu  sync.Mutex
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func newRPCServer(w io.Writer, concurrency, maxBatch int) *rpcServer {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	return &rpcServer{
+		methods: (&api{maxBatch: maxBatch}).methods(),
+		sem:     make(chan struct{}, concurrency),
+		w:       bw,
+		enc:     enc,
+	}
+}
+
+// serve handles the requests read from r and waits for the responses.
+func (s *rpcServer) serve(r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, maxRequestBody)
+	for sc.Scan() {
+		if line := bytes.TrimSpace(sc.Bytes()); len(line) > 0 {
+			s.handle(line)
+		}
+	}
+	s.wg.Wait()
+	return sc.Err()
+}
+
+func (s *rpcServer) send(resp *rpcResponse) {
+	resp.JSONRPC = "2.0"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.enc.Encode(resp)
+	if err == nil {
+		err = s.w.Flush()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func (s *rpcServer) sendError(id json.RawMessage, code int, msg string) {
+	if len(id) == 0 {
+		return // a notification
+	}
+	s.send(&rpcResponse{ID: id, Error: &rpcError{code, msg}})
+}
+
+// handle handles a request line; the methods are called concurrently,
+// so the requests can be pipelined.
+func (s *rpcServer) handle(line []byte) {
+	var req rpcRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		if json.Valid(line) {
+			s.sendError(nullID, rpcInvalidRequest, "invalid request")
+		} else {
+			s.sendError(nullID, rpcParseError, "parse error: "+err.Error())
+		}
+		return
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		s.sendError(req.ID, rpcInvalidRequest, "invalid request")
+		return
+	}
+	m, ok := s.methods[req.Method]
+	if !ok {
+		s.sendError(req.ID, rpcMethodNotFound, "method not found: "+req.Method)
+		return
+	}
+	params := m.newParams()
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, params); err != nil {
+			s.sendError(req.ID, rpcInvalidParams, "invalid params: "+err.Error())
+			return
+		}
+	}
+
+	s.sem <- struct{}{}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() { <-s.sem }()
+		res, err := m.call(context.Background(), params)
+		switch err.(type) {
+		case nil:
+			if len(req.ID) != 0 {
+				s.send(&rpcResponse{ID: req.ID, Result: res})
+			}
+		case *apiError:
+			s.sendError(req.ID, rpcInvalidParams, err.Error())
+		default:
+			s.sendError(req.ID, rpcInternalError, err.Error())
+		}
+	}()
+}
+
+func rpc(args []string) error {
+	fs := flag.NewFlagSet("rpc", flag.ExitOnError)
+	dict := fs.String("dict", "", "pymorphy2 dictionary `directory` (found using python if empty)")
+	concurrency := fs.Int("concurrency", runtime.GOMAXPROCS(0), "maximum number of requests analyzed at once")
+	maxBatch := fs.Int("max-batch", 10000, "maximum number of words in a request")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: morph rpc [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Rpc serves JSON-RPC 2.0 over the standard input and output, one message\n")
+		fmt.Fprintf(os.Stderr, "per line. The methods parse, xparse, lemmatize, inflect, lexeme and tokenize\n")
+		fmt.Fprintf(os.Stderr, "take the same parameters as the endpoints of morph serve. The requests are\n")
+		fmt.Fprintf(os.Stderr, "handled concurrently, so the responses may come in a different order;\n")
+		fmt.Fprintf(os.Stderr, "match them to the requests by id.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *concurrency <= 0 {
+		return errors.New("concurrency must be positive")
+	}
+
+	if err := initDict(*dict); err != nil {
+		return err
+	}
+
+	return newRPCServer(os.Stdout, *concurrency, *maxBatch).serve(os.Stdin)
+}
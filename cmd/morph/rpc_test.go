@@ -0,0 +1,100 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRPC(t *testing.T) {
+	tests := []struct {
+		request string
+		id      string // the id of the response; "" if there must be none
+		code    int    // the error code; 0 for a result
+	}{
+		{`{"jsonrpc":"2.0","id":1,"method":"tokenize","params":{"text":"Мама мыла раму."}}`, "1", 0},
+		{`{"jsonrpc":"2.0","id":"two","method":"nosuchmethod","params":{}}`, `"two"`, rpcMethodNotFound},
+		{`{"jsonrpc":"2.0","id":3,"method":"parse","params":{"words":"стали"}}`, "3", rpcInvalidParams},
+		{`{"jsonrpc":"2.0","id":4,"method":"parse","params":{}}`, "4", rpcInvalidParams},
+		{`{"jsonrpc":"2.0","id":5,"method":"parse","params":{"words":["а","б","в","г"]}}`, "5", rpcInvalidParams},
+		{`{"jsonrpc":"2.0","id":6,"method":`, "null", rpcParseError},
+		{`{"id":7,"method":"tokenize"}`, "7", rpcInvalidRequest},
+		{`[1]`, "null", rpcInvalidRequest},
+		// notifications
+		{`{"jsonrpc":"2.0","method":"tokenize","params":{"text":"Мама"}}`, "", 0},
+		{`{"jsonrpc":"2.0","method":"nosuchmethod"}`, "", 0},
+		{`{"jsonrpc":"2.0","method":"parse","params":{}}`, "", 0},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if err := newRPCServer(&out, 2, 3).serve(strings.NewReader(tt.request + "\n")); err != nil {
+			t.Fatal(err)
+		}
+		if tt.id == "" {
+			if out.Len() > 0 {
+				t.Errorf("%s: want no response to a notification, got %s", tt.request, &out)
+			}
+			continue
+		}
+
+		var resp struct {
+			JSONRPC string
+			ID      json.RawMessage
+			Result  json.RawMessage
+			Error   *rpcError
+		}
+		if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+			t.Errorf("%s: invalid response %q: %v", tt.request, &out, err)
+			continue
+		}
+		if resp.JSONRPC != "2.0" || string(resp.ID) != tt.id {
+			t.Errorf("%s: want jsonrpc 2.0 and id %s, got %s", tt.request, tt.id, &out)
+		}
+		switch {
+		case tt.code == 0 && (resp.Error != nil || len(resp.Result) == 0):
+			t.Errorf("%s: want a result, got %s", tt.request, &out)
+		case tt.code != 0 && (resp.Error == nil || resp.Error.Code != tt.code || resp.Result != nil):
+			t.Errorf("%s: want error %d, got %s", tt.request, tt.code, &out)
+		}
+	}
+}
+
+func TestRPCPipelined(t *testing.T) {
+	needDict(t)
+	in := `{"jsonrpc":"2.0","id":1,"method":"lemmatize","params":{"words":["стали"]}}
+{"jsonrpc":"2.0","method":"lemmatize","params":{"words":["стали"]}}
+{"jsonrpc":"2.0","id":2,"method":"inflect","params":{"requests":[{"word":"ёж","grammemes":["plur","datv"]}]}}
+`
+	var out bytes.Buffer
+	if err := newRPCServer(&out, 2, 10).serve(strings.NewReader(in)); err != nil {
+		t.Fatal(err)
+	}
+	ids := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var resp struct {
+			ID     json.RawMessage
+			Result []map[string]interface{}
+		}
+		if err := json.Unmarshal([]byte(line), &resp); err != nil || len(resp.Result) != 1 {
+			t.Errorf("want a result for one word, got %s", line)
+		}
+		ids[string(resp.ID)] = true
+	}
+	if len(ids) != 2 || !ids["1"] || !ids["2"] {
+		t.Errorf("want the responses to the requests 1 and 2, got %s", &out)
+	}
+}
@@ -0,0 +1,165 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/vbatushev/morph"
+)
+
+const maxRequestBody = 8 << 20
+
+type server struct {
+	sem chan struct{}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	if e, ok := err.(*apiError); ok {
+		code = e.status
+	} else if err == context.Canceled || err == context.DeadlineExceeded {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+// handle wraps an API method: it decodes the JSON request, limits
+// the number of the requests analyzed at once and encodes the result.
+func (s *server) handle(m method) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, &apiError{http.StatusMethodNotAllowed, "method not allowed"})
+			return
+		}
+		req := m.newParams()
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody)).Decode(req); err != nil {
+			writeError(w, &apiError{http.StatusBadRequest, "invalid request: " + err.Error()})
+			return
+		}
+
+		ctx := r.Context()
+		select {
+		case s.sem <- struct{}{}:
+			defer func() { <-s.sem }()
+		case <-ctx.Done():
+			writeError(w, ctx.Err())
+			return
+		}
+
+		res, err := m.call(ctx, req)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"results": res})
+	}
+}
+
+func health(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":     "ok",
+		"dictionary": morph.Info(),
+	})
+}
+
+// newHandler returns the handler of the API endpoints.
+func newHandler(concurrency, maxBatch int) http.Handler {
+	s := &server{sem: make(chan struct{}, concurrency)}
+	a := &api{maxBatch: maxBatch}
+	mux := http.NewServeMux()
+	for name, m := range a.methods() {
+		mux.HandleFunc("/"+name, s.handle(m))
+	}
+	mux.HandleFunc("/health", health)
+	return mux
+}
+
+func serve(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	dict := fs.String("dict", "", "pymorphy2 dictionary `directory` (found using python if empty)")
+	addr := fs.String("addr", "localhost:8080", "listen `address`")
+	concurrency := fs.Int("concurrency", runtime.GOMAXPROCS(0), "maximum number of requests analyzed at once")
+	maxBatch := fs.Int("max-batch", 10000, "maximum number of words in a request")
+	cacheSize := fs.Int("cache", 0, "number of analyzed words to cache (0 disables the cache)")
+	timeout := fs.Duration("shutdown-timeout", 10*time.Second, "time to wait for the active requests on shutdown")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: morph serve [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Serve serves the HTTP JSON API. The endpoints\n\n")
+		fmt.Fprintf(os.Stderr, "\tPOST /parse, /xparse, /lemmatize  {\"words\": [\"...\"]}\n")
+		fmt.Fprintf(os.Stderr, "\tPOST /inflect  {\"requests\": [{\"word\": \"...\", \"tag\": \"...\", \"grammemes\": [\"...\"]}]}\n")
+		fmt.Fprintf(os.Stderr, "\tPOST /lexeme   {\"requests\": [{\"word\": \"...\", \"tag\": \"...\"}]}\n")
+		fmt.Fprintf(os.Stderr, "\tPOST /tokenize {\"text\": \"...\", \"analyze\": true}\n")
+		fmt.Fprintf(os.Stderr, "\tPOST /complete {\"prefix\": \"...\", \"limit\": 10, \"attested_first\": true}\n")
+		fmt.Fprintf(os.Stderr, "\tPOST /match    {\"pattern\": \"к?ш?а\", \"min_len\": 5, \"max_len\": 5, \"grammemes\": [\"...\"], \"limit\": 10}\n")
+		fmt.Fprintf(os.Stderr, "\tGET  /health\n\n")
+		fmt.Fprintf(os.Stderr, "return {\"results\": [...]} with a result for each word or request.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *concurrency <= 0 {
+		return errors.New("concurrency must be positive")
+	}
+
+	if err := initDict(*dict); err != nil {
+		return err
+	}
+	if *cacheSize > 0 {
+		morph.EnableCache(morph.CacheOptions{DictSize: *cacheSize, PredictSize: *cacheSize})
+	}
+
+	srv := &http.Server{
+		Addr:         *addr,
+		Handler:      newHandler(*concurrency, *maxBatch),
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 60 * time.Second,
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		log.Print("shutting down")
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		defer cancel()
+		done <- srv.Shutdown(ctx)
+	}()
+
+	log.Printf("listening on %s", *addr)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return <-done
+}
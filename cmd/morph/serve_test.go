@@ -0,0 +1,124 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serveTest is a request to an endpoint and the expected response: the status
+// and, for the successful requests, a key every result must have.
+type serveTest struct {
+	path, body string
+	status     int
+	key        string
+}
+
+var serveTests = []serveTest{
+	{"/parse", `{"words": ["стали"]}`, http.StatusOK, "analyses"},
+	{"/parse", `{"words": [`, http.StatusBadRequest, ""},
+	{"/parse", `{}`, http.StatusBadRequest, ""},
+	{"/parse", `{"words": ["а", "б", "в", "г"]}`, http.StatusRequestEntityTooLarge, ""},
+	{"/xparse", `{"words": ["петя-робот"]}`, http.StatusOK, "analyses"},
+	{"/xparse", `{"words": "стали"}`, http.StatusBadRequest, ""},
+	{"/xparse", `{}`, http.StatusBadRequest, ""},
+	{"/lemmatize", `{"words": ["стали"]}`, http.StatusOK, "lemmas"},
+	{"/lemmatize", `[]`, http.StatusBadRequest, ""},
+	{"/lemmatize", `{}`, http.StatusBadRequest, ""},
+	{"/inflect", `{"requests": [{"word": "ёж", "grammemes": ["plur", "datv"]}]}`, http.StatusOK, "found"},
+	{"/inflect", `{"requests": {}}`, http.StatusBadRequest, ""},
+	{"/inflect", `{}`, http.StatusBadRequest, ""},
+	{"/lexeme", `{"requests": [{"word": "ёж"}]}`, http.StatusOK, "forms"},
+	{"/lexeme", `not json`, http.StatusBadRequest, ""},
+	{"/lexeme", `{}`, http.StatusBadRequest, ""},
+	{"/tokenize", `{"text": "Мама мыла раму."}`, http.StatusOK, "kind"},
+	{"/tokenize", `{"text": 1}`, http.StatusBadRequest, ""},
+	{"/tokenize", `{}`, http.StatusBadRequest, ""},
+	{"/complete", `{"prefix": "ежи", "limit": 3}`, http.StatusOK, "attested"},
+	{"/complete", `{"prefix": "еж", "attested_first": true}`, http.StatusBadRequest, ""},
+	{"/complete", `{"prefix"}`, http.StatusBadRequest, ""},
+	{"/complete", `{}`, http.StatusBadRequest, ""},
+	{"/match", `{"pattern": "к?т", "limit": 3}`, http.StatusOK, "norm"},
+	{"/match", `{"pattern": "*кот"}`, http.StatusBadRequest, ""},
+	{"/match", `{"pattern": [}`, http.StatusBadRequest, ""},
+	{"/match", `{}`, http.StatusBadRequest, ""},
+}
+
+func TestServe(t *testing.T) {
+	h := newHandler(2, 3)
+	for _, tt := range serveTests {
+		if tt.status == http.StatusOK && !dictLoaded {
+			continue
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+		if rec.Code != tt.status {
+			t.Errorf("POST %s %s: want status %d, got %d: %s", tt.path, tt.body, tt.status, rec.Code, rec.Body)
+			continue
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+			t.Errorf("POST %s %s: want a JSON response, got %s", tt.path, tt.body, ct)
+		}
+
+		if tt.status != http.StatusOK {
+			var resp struct{ Error string }
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Error == "" {
+				t.Errorf("POST %s %s: want {\"error\": ...}, got %s", tt.path, tt.body, rec.Body)
+			}
+			continue
+		}
+		var resp struct{ Results []map[string]interface{} }
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || len(resp.Results) == 0 {
+			t.Errorf("POST %s %s: want {\"results\": [...]}, got %s", tt.path, tt.body, rec.Body)
+			continue
+		}
+		for _, r := range resp.Results {
+			if _, ok := r[tt.key]; !ok {
+				t.Errorf("POST %s %s: want %q in every result, got %s", tt.path, tt.body, tt.key, rec.Body)
+				break
+			}
+		}
+	}
+}
+
+func TestServeMethods(t *testing.T) {
+	h := newHandler(1, 10)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/parse", nil))
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != http.MethodPost {
+		t.Errorf("GET /parse: want status %d with Allow: POST, got %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/nosuchmethod", strings.NewReader("{}")))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("POST /nosuchmethod: want status %d, got %d", http.StatusNotFound, rec.Code)
+	}
+
+	needDict(t)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	var resp struct {
+		Status     string
+		Dictionary map[string]interface{}
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK || resp.Status != "ok" || resp.Dictionary == nil {
+		t.Errorf("GET /health: got %d %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,161 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vbatushev/morph"
+)
+
+func trainProbs(args []string) error {
+	fs := flag.NewFlagSet("train-probs", flag.ExitOnError)
+	dict := fs.String("dict", "", "pymorphy2 dictionary `directory` (found using python if empty)")
+	format := fs.String("format", "", "corpus `format`: opencorpora or conllu (guessed from the file extension if empty)")
+	alpha := fs.Float64("alpha", 0.5, "additive smoothing parameter")
+	minCount := fs.Int("min-count", 1, "minimum number of occurrences of a word")
+	out := fs.String("o", "p_t_given_w.intdawg", "output `file`")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: morph train-probs [flags] corpus...\n\n")
+		fmt.Fprintf(os.Stderr, "The output can replace p_t_given_w.intdawg of a dictionary\n")
+		fmt.Fprintf(os.Stderr, "or be loaded with morph.LoadProbabilities.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if err := initDict(*dict); err != nil {
+		return err
+	}
+
+	t := morph.NewProbTrainer()
+	t.Alpha = *alpha
+	t.MinCount = *minCount
+	for _, fn := range fs.Args() {
+		if err := readCorpus(fn, *format, func(sentence []morph.CorpusToken) error {
+			t.Add(sentence)
+			return nil
+		}); err != nil {
+			return fmt.Errorf("%s: %v", fn, err)
+		}
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err := t.Write(w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	matched, unmatched := t.Stats()
+	log.Printf("%d ambiguous tokens matched, %d did not match any analysis", matched, unmatched)
+	if unknown := t.UnknownGrammemes(); len(unknown) > 0 {
+		var names []string
+		for name := range unknown {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			log.Printf("unknown grammeme %s in %d tokens", name, unknown[name])
+		}
+	}
+	return nil
+}
+
+func trainHMM(args []string) error {
+	fs := flag.NewFlagSet("train-hmm", flag.ExitOnError)
+	dict := fs.String("dict", "", "pymorphy2 dictionary `directory` (found using python if empty)")
+	format := fs.String("format", "", "corpus `format`: opencorpora or conllu (guessed from the file extension if empty)")
+	out := fs.String("o", "hmm.json", "output `file`")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: morph train-hmm [flags] corpus...\n\n")
+		fmt.Fprintf(os.Stderr, "The output can be passed to the -hmm flag of morph conllu and morph mystem\n")
+		fmt.Fprintf(os.Stderr, "or loaded with morph.LoadHMM.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if err := initDict(*dict); err != nil {
+		return err
+	}
+
+	t := morph.NewHMMTrainer()
+	added, skipped := 0, 0
+	for _, fn := range fs.Args() {
+		if err := readCorpus(fn, *format, func(sentence []morph.CorpusToken) error {
+			if t.AddTokens(sentence) {
+				added++
+			} else {
+				skipped++
+			}
+			return nil
+		}); err != nil {
+			return fmt.Errorf("%s: %v", fn, err)
+		}
+	}
+	log.Printf("%d sentences added, %d skipped as having tokens with unknown tags", added, skipped)
+	if added == 0 {
+		return errors.New("no sentences to train the model on")
+	}
+	return t.Model().Save(*out)
+}
+
+func readCorpus(fn, format string, add func([]morph.CorpusToken) error) error {
+	if format == "" {
+		format = "conllu"
+		if strings.EqualFold(filepath.Ext(fn), ".xml") {
+			format = "opencorpora"
+		}
+	}
+
+	f, err := os.Open(fn)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	switch format {
+	case "opencorpora":
+		return morph.ReadOpenCorpora(r, add)
+	case "conllu":
+		return morph.ReadCoNLLUTokens(r, add)
+	}
+	return fmt.Errorf("unknown corpus format: %s", format)
+}
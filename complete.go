@@ -0,0 +1,142 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"bytes"
+	"context"
+	"sort"
+)
+
+// ctxCheckInterval is the number of the dawg keys read between
+// the checks of the context in the long walks of the dictionary.
+const ctxCheckInterval = 4096
+
+// Completion is a dictionary word completing a prefix.
+type Completion struct {
+	Word     string   // the word with the letter ё fixed
+	Norms    []string // the normal forms of the analyses of the word
+	Tags     []string // the tags of the analyses, parallel to Norms
+	Attested bool     // the word was seen in the corpus the probabilities were estimated from
+}
+
+// Complete returns up to limit (all if limit <= 0) dictionary words starting
+// with the (lowercase) prefix in the dictionary order (the byte order of
+// their UTF-8 encoding, where ё goes after я), with their analyses
+// sorted by probability. As in Parse, the letter е in the prefix matches
+// ё in the dictionary, so "ежи" is completed with "ёжик".
+// It stops enumerating the words as soon as it has enough of them,
+// so it is fast enough for completing what is typed in a search box.
+func Complete(prefix string, limit int) []Completion {
+	if probDAWG == nil {
+		panic("not initialized; call Init or InitWith")
+	}
+	words, _ := completeWords(context.Background(), prefix, limit)
+	sort.Strings(words)
+	if limit > 0 && len(words) > limit {
+		words = words[:limit]
+	}
+	return completions(words)
+}
+
+// CompleteAttestedFirst is like Complete, but it orders the completions
+// as follows: the words seen in the corpus the probabilities were estimated
+// from (i.e. having P(tag|word) in the dictionary) go first, then the shorter
+// ones. This is not a ranking by frequency: the dictionary has no word
+// frequencies, and only the ambiguous words have the probabilities.
+// It reads up to maxScan (all if maxScan <= 0) words with the prefix
+// for each spelling of the prefix, so the completions of a short prefix
+// come only from the first words in the dictionary order unless maxScan
+// is large, and then the scan takes a while. If ctx is cancelled during
+// the scan, CompleteAttestedFirst returns ctx.Err().
+func CompleteAttestedFirst(ctx context.Context, prefix string, limit, maxScan int) ([]Completion, error) {
+	if probDAWG == nil {
+		panic("not initialized; call Init or InitWith")
+	}
+	words, err := completeWords(ctx, prefix, maxScan)
+	if err != nil {
+		return nil, err
+	}
+	attested := make(map[string]bool, len(words))
+	for _, w := range words {
+		attested[w] = isAttested(w)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		a, b := words[i], words[j]
+		if attested[a] != attested[b] {
+			return attested[a]
+		}
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	})
+	if limit > 0 && len(words) > limit {
+		words = words[:limit]
+	}
+	return completions(words), nil
+}
+
+// completeWords returns the distinct dictionary words starting with
+// the prefix, up to limit (all if limit <= 0) for each spelling of
+// the prefix with е or ё. It checks ctx every ctxCheckInterval keys.
+func completeWords(ctx context.Context, prefix string, limit int) ([]string, error) {
+	var c completer
+	c.init(wordsDAWG.Dict, wordsDAWG.Guide)
+	var keyBuf [4]similarKey
+
+	var words []string
+	keys := 0
+	for _, sk := range wordsDAWG.similarPrefixes(keyBuf[:0], prefix) {
+		n := 0
+		var last []byte
+		c.start(sk.index, sk.key)
+		for (limit <= 0 || n < limit) && c.next() {
+			if keys%ctxCheckInterval == 0 {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+			}
+			keys++
+			// the keys are the words followed by the separator and the payload
+			i := bytes.IndexByte(c.key, payloadSeparator)
+			if i < 0 || bytes.Equal(c.key[:i], last) {
+				continue
+			}
+			last = append(last[:0], c.key[:i]...)
+			words = append(words, string(last))
+			n++
+		}
+	}
+	return words, nil
+}
+
+// completions returns the completions for the dictionary words.
+func completions(words []string) []Completion {
+	cs := make([]Completion, len(words))
+	for i, w := range words {
+		c := Completion{Word: w, Attested: isAttested(w)}
+		ws, norms, ids := parse(w)
+		for j := range ws {
+			// parse returns the spellings with ё as well
+			if ws[j] == w {
+				c.Norms = append(c.Norms, norms[j])
+				c.Tags = append(c.Tags, tags[ids[j]])
+			}
+		}
+		externalTags(c.Tags)
+		cs[i] = c
+	}
+	return cs
+}
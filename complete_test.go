@@ -0,0 +1,71 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	cs := Complete("ежик", 10)
+	if len(cs) == 0 || len(cs) > 10 {
+		t.Fatalf("Complete(ежик, 10): want 1 to 10 completions, got %d", len(cs))
+	}
+	words := make([]string, len(cs))
+	for i, c := range cs {
+		words[i] = c.Word
+		if !strings.HasPrefix(strings.Replace(c.Word, "ё", "е", -1), "ежик") {
+			t.Errorf("Complete(ежик): %s does not start with the prefix", c.Word)
+		}
+		if len(c.Norms) == 0 || len(c.Norms) != len(c.Tags) {
+			t.Errorf("Complete(ежик): %s has %d norms and %d tags", c.Word, len(c.Norms), len(c.Tags))
+		}
+	}
+	if words[0] != "ёжик" || cs[0].Norms[0] != "ёжик" {
+		t.Errorf("Complete(ежик): want ёжик first, got %v", words)
+	}
+	if !sort.StringsAreSorted(words) {
+		t.Errorf("Complete(ежик): want the words sorted, got %v", words)
+	}
+	if cs := Complete("ъъъ", 10); len(cs) != 0 {
+		t.Errorf("Complete(ъъъ): want no completions, got %v", cs)
+	}
+}
+
+func TestCompleteAttestedFirst(t *testing.T) {
+	cs, err := CompleteAttestedFirst(context.Background(), "кош", 5, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cs) != 5 {
+		t.Fatalf("CompleteAttestedFirst(кош, 5): want 5 completions, got %d", len(cs))
+	}
+	if !cs[0].Attested {
+		t.Errorf("CompleteAttestedFirst(кош): want an attested word first, got %s", cs[0].Word)
+	}
+	for i := 1; i < len(cs); i++ {
+		if cs[i].Attested && !cs[i-1].Attested {
+			t.Errorf("CompleteAttestedFirst(кош): %s goes after the unattested %s", cs[i].Word, cs[i-1].Word)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := CompleteAttestedFirst(ctx, "", 5, 0); err != context.Canceled {
+		t.Errorf("CompleteAttestedFirst with a cancelled context: want context.Canceled, got %v", err)
+	}
+}
@@ -19,19 +19,28 @@ type completer struct {
 	lastIndex  uint32
 	indexStack []uint32
 	key        []byte
+
+	// initial backing arrays for indexStack and key,
+	// large enough for the payloads of the pymorphy2 dawgs
+	stackBuf [16]uint32
+	keyBuf   [16]byte
 }
 
-func newCompleter(d dictionary, g guide) *completer {
-	return &completer{
-		dict:  d,
-		guide: g,
-	}
+func (c *completer) init(d dictionary, g guide) {
+	c.dict = d
+	c.guide = g
 }
 
 func (c *completer) start(index uint32, prefix string) {
-	c.key = []byte(prefix)
+	if c.key == nil {
+		c.key = c.keyBuf[:0]
+		c.indexStack = c.stackBuf[:0]
+	}
+	c.key = append(c.key[:0], prefix...)
+	c.indexStack = c.indexStack[:0]
+	c.lastIndex = 0
 	if len(c.guide) > 0 {
-		c.indexStack = []uint32{index}
+		c.indexStack = append(c.indexStack, index)
 	}
 }
 
@@ -43,6 +52,14 @@ func (c *completer) next() bool {
 	index := c.indexStack[len(c.indexStack)-1]
 
 	if c.lastIndex != 0 {
+		if childLabel := c.guide.child(index); childLabel != 0 {
+			// the last key is a prefix of the next one
+			if index = c.follow(childLabel, index); index == 0 {
+				return false
+			}
+			return c.findTerminal(index)
+		}
+
 		for {
 			siblingLabel := c.guide.sibling(index)
 			if len(c.key) > 0 {
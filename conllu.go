@@ -0,0 +1,292 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CoNLLUToken is a line of a CoNLL-U sentence: a word, a multiword token
+// or an empty node. The fields hold the columns as they are, with "_"
+// for the empty ones.
+type CoNLLUToken struct {
+	ID     string
+	Form   string
+	Lemma  string
+	UPOS   string
+	XPOS   string
+	Feats  string
+	Head   string
+	DepRel string
+	Deps   string
+	Misc   string
+}
+
+// IsWord reports whether the token is a word rather than
+// a multiword token (1-2) or an empty node (1.1).
+func (t *CoNLLUToken) IsWord() bool {
+	return !strings.ContainsAny(t.ID, "-.")
+}
+
+// CoNLLUSentence is a sentence of a CoNLL-U file.
+type CoNLLUSentence struct {
+	Comments []string // the comment lines, including the leading #
+	Tokens   []CoNLLUToken
+}
+
+// CoNLLUReader reads the sentences of a CoNLL-U file.
+type CoNLLUReader struct {
+	s    *bufio.Scanner
+	line int
+}
+
+// NewCoNLLUReader returns a new reader reading from r.
+func NewCoNLLUReader(r io.Reader) *CoNLLUReader {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1<<20)
+	return &CoNLLUReader{s: s}
+}
+
+// Read returns the next sentence; at the end of the input it returns io.EOF.
+func (r *CoNLLUReader) Read() (*CoNLLUSentence, error) {
+	var sent *CoNLLUSentence
+	for r.s.Scan() {
+		r.line++
+		line := strings.TrimSuffix(r.s.Text(), "\r")
+		if line == "" {
+			if sent != nil {
+				return sent, nil
+			}
+			continue
+		}
+		if sent == nil {
+			sent = new(CoNLLUSentence)
+		}
+		if strings.HasPrefix(line, "#") {
+			sent.Comments = append(sent.Comments, line)
+			continue
+		}
+
+		cols := strings.Split(line, "\t")
+		if len(cols) != 10 {
+			return nil, fmt.Errorf("line %d: want 10 columns, got %d", r.line, len(cols))
+		}
+		sent.Tokens = append(sent.Tokens, CoNLLUToken{
+			cols[0], cols[1], cols[2], cols[3], cols[4],
+			cols[5], cols[6], cols[7], cols[8], cols[9],
+		})
+	}
+	if err := r.s.Err(); err != nil {
+		return nil, err
+	}
+	if sent != nil {
+		return sent, nil
+	}
+	return nil, io.EOF
+}
+
+// WriteCoNLLU writes the sentence to w in the CoNLL-U format,
+// followed by a blank line.
+func WriteCoNLLU(w io.Writer, s *CoNLLUSentence) error {
+	bw := bufio.NewWriter(w)
+	for _, c := range s.Comments {
+		bw.WriteString(c)
+		bw.WriteByte('\n')
+	}
+	for _, t := range s.Tokens {
+		cols := [...]string{t.ID, t.Form, t.Lemma, t.UPOS, t.XPOS, t.Feats, t.Head, t.DepRel, t.Deps, t.Misc}
+		for i, c := range cols {
+			if c == "" {
+				c = "_"
+			}
+			if i > 0 {
+				bw.WriteByte('\t')
+			}
+			bw.WriteString(c)
+		}
+		bw.WriteByte('\n')
+	}
+	bw.WriteByte('\n')
+	return bw.Flush()
+}
+
+// Annotator fills in the empty LEMMA, UPOS, XPOS and FEATS columns
+// of CoNLL-U sentences. XPOS gets the OpenCorpora tag with the space
+// replaced with a comma, as CoNLL-U does not allow spaces in the columns
+// (ReadCoNLLUTokens reads it back), and UPOS and FEATS get its
+// conversion by Tag.UD.
+type Annotator struct {
+	// HMM, if not nil, is used to choose the analyses of the words
+	// in context; otherwise the most probable analysis is chosen.
+	HMM *HMM
+}
+
+// Annotate fills in the empty columns of the words of the sentence,
+// leaving the filled ones untouched. The analyses agreeing with the
+// filled XPOS or UPOS column of a word are preferred.
+func (a *Annotator) Annotate(s *CoNLLUSentence) {
+	if probDAWG == nil {
+		panic("not initialized; call Init or InitWith")
+	}
+
+	var words []*CoNLLUToken
+	var forms []string
+	for i := range s.Tokens {
+		if t := &s.Tokens[i]; t.IsWord() {
+			words = append(words, t)
+			forms = append(forms, t.Form)
+		}
+	}
+	var best []string
+	if a.HMM != nil {
+		_, _, best = a.HMM.disambiguate(forms)
+	}
+
+	for i, t := range words {
+		prefer := ""
+		if best != nil {
+			prefer = best[i]
+		}
+		norm, tag := chooseAnalysis(t, prefer)
+		upos, feats := LookupTag(tag).UD()
+		fillColumn(&t.Lemma, norm)
+		fillColumn(&t.XPOS, strings.Replace(tag, " ", ",", -1))
+		fillColumn(&t.UPOS, upos)
+		fillColumn(&t.Feats, feats)
+	}
+}
+
+func filled(col string) bool {
+	return col != "_" && col != ""
+}
+
+func fillColumn(col *string, v string) {
+	if !filled(*col) {
+		*col = v
+	}
+}
+
+// chooseAnalysis returns the normal form and the tag of the analysis of the
+// word agreeing with its filled columns; prefer is the tag to choose if it
+// agrees with them. If no analysis agrees, it returns the one closest
+// to them: sharing the most grammemes with XPOS and then having the UPOS.
+func chooseAnalysis(t *CoNLLUToken, prefer string) (norm, tag string) {
+	form := strings.ToLower(t.Form)
+	_, norms, ids := xparse(form)
+	tags := tagNames(ids)
+	if len(tags) == 0 {
+		switch {
+		case filled(t.XPOS):
+			return form, t.XPOS
+		case prefer != "":
+			return form, prefer
+		}
+		return form, fallbackTag(form)
+	}
+
+	agrees := func(i int) bool {
+		if filled(t.XPOS) && !LookupTag(tags[i]).Equal(LookupTag(t.XPOS)) {
+			return false
+		}
+		if filled(t.UPOS) {
+			if upos, _ := LookupTag(tags[i]).UD(); upos != t.UPOS {
+				return false
+			}
+		}
+		return true
+	}
+	for i := range tags {
+		if tags[i] == prefer && agrees(i) {
+			return norms[i], tags[i]
+		}
+	}
+	for i := range tags {
+		if agrees(i) {
+			return norms[i], tags[i]
+		}
+	}
+
+	var xpos GrammemeSet
+	if filled(t.XPOS) {
+		xpos = LookupTag(t.XPOS).set
+	}
+	score := func(i int) int {
+		tag := LookupTag(tags[i])
+		s := 2 * tag.set.intersect(xpos).count()
+		if upos, _ := tag.UD(); upos == t.UPOS {
+			s++
+		}
+		return s
+	}
+	best := 0
+	for i := range tags {
+		if tags[i] == prefer {
+			best = i
+			break
+		}
+	}
+	for i := range tags {
+		if score(i) > score(best) {
+			best = i
+		}
+	}
+	return norms[best], tags[best]
+}
+
+// Evaluation holds the numbers of the words with the gold UPOS
+// and of the correctly annotated ones, as counted by Annotator.Evaluate.
+type Evaluation struct {
+	Words  int
+	UPOS   int
+	Feats  int
+	Lemmas int
+}
+
+// Evaluate annotates a copy of the gold sentence with its LEMMA, UPOS, XPOS
+// and FEATS columns cleared and adds the results of the comparison
+// with the gold annotation to e. The lemmas are compared ignoring the case
+// and the difference between е and ё.
+func (a *Annotator) Evaluate(gold *CoNLLUSentence, e *Evaluation) {
+	s := &CoNLLUSentence{Tokens: make([]CoNLLUToken, len(gold.Tokens))}
+	copy(s.Tokens, gold.Tokens)
+	for i := range s.Tokens {
+		t := &s.Tokens[i]
+		t.Lemma, t.UPOS, t.XPOS, t.Feats = "_", "_", "_", "_"
+	}
+	a.Annotate(s)
+
+	for i, g := range gold.Tokens {
+		if !g.IsWord() || !filled(g.UPOS) {
+			continue
+		}
+		t := s.Tokens[i]
+		e.Words++
+		if t.UPOS == g.UPOS {
+			e.UPOS++
+		}
+		if t.Feats == g.Feats {
+			e.Feats++
+		}
+		if normalizeLemma(t.Lemma) == normalizeLemma(g.Lemma) {
+			e.Lemmas++
+		}
+	}
+}
+
+func normalizeLemma(s string) string {
+	return strings.Replace(strings.ToLower(s), "ё", "е", -1)
+}
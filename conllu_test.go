@@ -0,0 +1,132 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func readTestCoNLLU(t *testing.T, text string) []*CoNLLUSentence {
+	var sents []*CoNLLUSentence
+	r := NewCoNLLUReader(strings.NewReader(text))
+	for {
+		s, err := r.Read()
+		if err == io.EOF {
+			return sents
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		sents = append(sents, s)
+	}
+}
+
+func TestCoNLLUReadWrite(t *testing.T) {
+	sents := readTestCoNLLU(t, testCoNLLU)
+	if len(sents) != 2 || len(sents[0].Comments) != 1 || len(sents[0].Tokens) != 4 || len(sents[1].Tokens) != 3 {
+		t.Fatalf("Read: got %+v", sents)
+	}
+	if sents[1].Tokens[0].IsWord() || !sents[1].Tokens[1].IsWord() {
+		t.Errorf("IsWord: wrong for %+v", sents[1].Tokens[:2])
+	}
+
+	var buf bytes.Buffer
+	for _, s := range sents {
+		if err := WriteCoNLLU(&buf, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := buf.String(); got != testCoNLLU+"\n" {
+		t.Errorf("WriteCoNLLU: want\n%s\ngot\n%s", testCoNLLU+"\n", got)
+	}
+
+	if _, err := NewCoNLLUReader(strings.NewReader("1\tx\n")).Read(); err == nil {
+		t.Error("Read: want an error for a line with 2 columns")
+	}
+}
+
+func TestMatchUD(t *testing.T) {
+	tags := []string{
+		"VERB,perf,intr plur,past,indc",
+		"NOUN,inan,femn sing,gent",
+		"NOUN,inan,femn plur,nomn",
+		"NOUN,inan,femn plur,accs",
+	}
+	tests := []struct {
+		upos, feats, want string
+	}{
+		{"VERB", "_", tags[0]},
+		{"NOUN", "Case=Gen|Number=Sing", tags[1]},
+		{"NOUN", "Animacy=Inan|Case=Nom|Gender=Fem|Number=Plur", tags[2]},
+		{"NOUN", "Number=Plur", ""},
+		{"NOUN", "Case=Dat", ""},
+		{"ADJ", "_", ""},
+	}
+	for _, tt := range tests {
+		if got := matchUD(tags, tt.upos, tt.feats); got != tt.want {
+			t.Errorf("matchUD(%s, %s): want %q, got %q", tt.upos, tt.feats, tt.want, got)
+		}
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	const text = "1\tМама\t_\t_\t_\t_\t2\tnsubj\t_\t_\n" +
+		"2\tстали\t_\tNOUN\t_\t_\t0\troot\t_\t_\n" +
+		"3\t.\t_\t_\t_\t_\t2\tpunct\t_\t_\n"
+	s := readTestCoNLLU(t, text)[0]
+	var a Annotator
+	a.Annotate(s)
+
+	want := []CoNLLUToken{
+		{"1", "Мама", "мама", "NOUN", "NOUN,anim,femn,sing,nomn", "Animacy=Anim|Case=Nom|Gender=Fem|Number=Sing", "2", "nsubj", "_", "_"},
+		{"2", "стали", "сталь", "NOUN", "", "", "0", "root", "_", "_"},
+		{"3", ".", ".", "PUNCT", "PNCT", "_", "2", "punct", "_", "_"},
+	}
+	for i, tok := range s.Tokens {
+		w := want[i]
+		if w.XPOS == "" {
+			// one of the noun analyses
+			if tok.Lemma != w.Lemma || tok.UPOS != w.UPOS || !strings.HasPrefix(tok.XPOS, "NOUN") {
+				t.Errorf("Annotate: token %d: got %+v", i+1, tok)
+			}
+			continue
+		}
+		if tok != w {
+			t.Errorf("Annotate: token %d: want %+v, got %+v", i+1, w, tok)
+		}
+	}
+
+	// no analysis agrees with the masculine XPOS: the closest one
+	// is taken along with its normal form
+	tok := CoNLLUToken{Form: "стали", XPOS: "NOUN,inan,masc sing,nomn"}
+	norm, tag := chooseAnalysis(&tok, "")
+	words, norms, tags := XParse("стали")
+	found := false
+	for i := range words {
+		found = found || norms[i] == norm && tags[i] == tag
+	}
+	if norm != "сталь" || !found {
+		t.Errorf("chooseAnalysis: want an analysis of сталь, got %s %s", norm, tag)
+	}
+
+	// стали may get the verb analysis without the UPOS hint
+	var e Evaluation
+	a.Evaluate(s, &e)
+	if e.Words != 3 || e.UPOS < 2 || e.Lemmas < 2 {
+		t.Errorf("Evaluate: want at least 2 of the 3 words right, got %+v", e)
+	}
+}
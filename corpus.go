@@ -0,0 +1,147 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// CorpusToken is a token of an annotated corpus.
+type CorpusToken struct {
+	Form  string
+	Lemma string
+	Tag   string // OpenCorpora grammemes separated by commas; empty if unknown or ambiguous
+
+	// the Universal Dependencies part of speech and features, if known
+	// (CoNLL-U only); they are used if the Tag is empty
+	UPOS  string
+	Feats string
+}
+
+// ReadOpenCorpora reads the sentences of the OpenCorpora XML corpus
+// (annot.opcorpora.xml) and calls fn for each of them.
+// The tokens with several variants of annotation get an empty Tag.
+// The sentence slice is reused after fn returns.
+func ReadOpenCorpora(r io.Reader, fn func(sentence []CorpusToken) error) error {
+	d := xml.NewDecoder(r)
+
+	var (
+		sentence []CorpusToken
+		tok      CorpusToken
+		grams    []string
+		variants int
+	)
+	for {
+		t, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := t.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "sentence":
+				sentence = sentence[:0]
+			case "token":
+				tok = CorpusToken{Form: xmlAttr(t, "text")}
+				grams = grams[:0]
+				variants = 0
+			case "v":
+				variants++
+			case "l":
+				if variants == 1 {
+					tok.Lemma = xmlAttr(t, "t")
+				}
+			case "g":
+				if variants == 1 {
+					grams = append(grams, xmlAttr(t, "v"))
+				}
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "token":
+				if variants == 1 {
+					tok.Tag = strings.Join(grams, ",")
+				} else {
+					tok.Lemma = ""
+				}
+				sentence = append(sentence, tok)
+			case "sentence":
+				if err := fn(sentence); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func xmlAttr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// ReadCoNLLUTokens reads the sentences of a CoNLL-U corpus and calls fn
+// for each of them. The Tag of a token is taken from the XPOS column,
+// which is expected to hold OpenCorpora grammemes, the UPOS and Feats
+// from the UPOS and FEATS columns; multiword tokens and empty nodes are
+// skipped. The sentence slice is reused after fn returns.
+func ReadCoNLLUTokens(r io.Reader, fn func(sentence []CorpusToken) error) error {
+	cr := NewCoNLLUReader(r)
+	var sentence []CorpusToken
+	for {
+		s, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		sentence = sentence[:0]
+		for _, t := range s.Tokens {
+			if !t.IsWord() {
+				continue
+			}
+			tok := CorpusToken{Form: t.Form}
+			if filled(t.Lemma) {
+				tok.Lemma = t.Lemma
+			}
+			if filled(t.XPOS) {
+				tok.Tag = strings.Replace(t.XPOS, " ", ",", -1)
+			}
+			if filled(t.UPOS) {
+				tok.UPOS = t.UPOS
+			}
+			if filled(t.Feats) {
+				tok.Feats = t.Feats
+			}
+			sentence = append(sentence, tok)
+		}
+		if len(sentence) == 0 {
+			continue
+		}
+		if err := fn(sentence); err != nil {
+			return err
+		}
+	}
+}
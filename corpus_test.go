@@ -0,0 +1,87 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testOpenCorpora = `<?xml version="1.0" encoding="utf-8" standalone="yes"?>
+<annotation version="0.12" revision="4191549">
+<text id="1" parent="0" name="Тест">
+<paragraphs><paragraph id="1"><sentence id="1"><source>Они стали.</source><tokens>
+<token id="1" text="Они"><tfr rev_id="1" t="Они"><v><l id="1" t="они"><g v="NPRO"/><g v="plur"/><g v="3per"/><g v="nomn"/></l></v></tfr></token>
+<token id="2" text="стали"><tfr rev_id="2" t="стали"><v><l id="2" t="стать"><g v="VERB"/><g v="perf"/><g v="intr"/><g v="plur"/><g v="past"/><g v="indc"/></l></v><v><l id="3" t="сталь"><g v="NOUN"/><g v="inan"/><g v="femn"/><g v="plur"/><g v="nomn"/></l></v></tfr></token>
+<token id="3" text="."><tfr rev_id="3" t="."><v><l id="0" t="."><g v="PNCT"/></l></v></tfr></token>
+</tokens></sentence></paragraph></paragraphs>
+</text>
+</annotation>`
+
+func TestReadOpenCorpora(t *testing.T) {
+	var got [][]CorpusToken
+	err := ReadOpenCorpora(strings.NewReader(testOpenCorpora), func(s []CorpusToken) error {
+		got = append(got, append([]CorpusToken(nil), s...))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]CorpusToken{{
+		{Form: "Они", Lemma: "они", Tag: "NPRO,plur,3per,nomn"},
+		{Form: "стали"},
+		{Form: ".", Lemma: ".", Tag: "PNCT"},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadOpenCorpora: want %v, got %v", want, got)
+	}
+}
+
+const testCoNLLU = `# text = Нож из стали.
+1	Нож	нож	NOUN	NOUN,inan,masc sing,nomn	_	0	root	_	_
+2	из	из	ADP	PREP	_	3	case	_	_
+3	стали	_	NOUN	_	_	1	nmod	_	SpaceAfter=No
+4	.	.	PUNCT	PNCT	_	1	punct	_	_
+
+1-2	Вот-вот	_	_	_	_	_	_	_	_
+1	Вот	вот	PART	PRCL	_	0	root	_	_
+2	-вот	вот	PART	PRCL	_	1	fixed	_	_
+`
+
+func TestReadCoNLLUTokens(t *testing.T) {
+	var got [][]CorpusToken
+	err := ReadCoNLLUTokens(strings.NewReader(testCoNLLU), func(s []CorpusToken) error {
+		got = append(got, append([]CorpusToken(nil), s...))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]CorpusToken{
+		{
+			{Form: "Нож", Lemma: "нож", Tag: "NOUN,inan,masc,sing,nomn", UPOS: "NOUN"},
+			{Form: "из", Lemma: "из", Tag: "PREP", UPOS: "ADP"},
+			{Form: "стали", UPOS: "NOUN"},
+			{Form: ".", Lemma: ".", Tag: "PNCT", UPOS: "PUNCT"},
+		},
+		{
+			{Form: "Вот", Lemma: "вот", Tag: "PRCL", UPOS: "PART"},
+			{Form: "-вот", Lemma: "вот", Tag: "PRCL", UPOS: "PART"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadCoNLLUTokens: want %v, got %v", want, got)
+	}
+}
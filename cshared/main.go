@@ -0,0 +1,178 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Command cshared builds the morph package as a C shared library:
+//
+//	go build -buildmode=c-shared -o libmorph.so
+//
+// The C interface is declared in morph.h.
+package main
+
+/*
+#include <stdlib.h>
+#define MORPH_NO_PROTOTYPES
+#include "morph.h"
+*/
+import "C"
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"github.com/vbatushev/morph"
+)
+
+var (
+	mu          sync.Mutex
+	initialized bool
+)
+
+func main() {}
+
+func ready() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return initialized
+}
+
+//export morph_init
+func morph_init(dir *C.char) *C.char {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var err error
+	if dir == nil {
+		err = morph.Init()
+	} else {
+		err = morph.InitWith(C.GoString(dir))
+	}
+	if err != nil {
+		return C.CString(err.Error())
+	}
+	initialized = true
+	return nil
+}
+
+func analyses(words, norms, tags []string) *C.morph_analyses {
+	a := (*C.morph_analyses)(C.calloc(1, C.size_t(unsafe.Sizeof(C.morph_analyses{}))))
+	n := len(words)
+	if n == 0 {
+		return a
+	}
+	a.len = C.size_t(n)
+	a.items = (*C.morph_analysis)(C.calloc(C.size_t(n), C.size_t(unsafe.Sizeof(C.morph_analysis{}))))
+	items := unsafe.Slice(a.items, n)
+	for i := range items {
+		items[i].word = C.CString(words[i])
+		items[i].norm = C.CString(norms[i])
+		items[i].tag = C.CString(tags[i])
+	}
+	return a
+}
+
+//export morph_parse
+func morph_parse(word *C.char) *C.morph_analyses {
+	if !ready() {
+		return nil
+	}
+	return analyses(morph.Parse(C.GoString(word)))
+}
+
+//export morph_xparse
+func morph_xparse(word *C.char) *C.morph_analyses {
+	if !ready() {
+		return nil
+	}
+	return analyses(morph.XParse(C.GoString(word)))
+}
+
+//export morph_free_analyses
+func morph_free_analyses(a *C.morph_analyses) {
+	if a == nil {
+		return
+	}
+	if n := int(a.len); n > 0 {
+		for _, it := range unsafe.Slice(a.items, n) {
+			C.free(unsafe.Pointer(it.word))
+			C.free(unsafe.Pointer(it.norm))
+			C.free(unsafe.Pointer(it.tag))
+		}
+	}
+	C.free(unsafe.Pointer(a.items))
+	C.free(unsafe.Pointer(a))
+}
+
+type analysis struct {
+	Word string `json:"word"`
+	Norm string `json:"norm"`
+	Tag  string `json:"tag"`
+}
+
+func analysesJSON(words, norms, tags []string) *C.char {
+	as := make([]analysis, len(words))
+	for i := range words {
+		as[i] = analysis{words[i], norms[i], tags[i]}
+	}
+	b, err := json.Marshal(as)
+	if err != nil {
+		return nil
+	}
+	return C.CString(string(b))
+}
+
+//export morph_parse_json
+func morph_parse_json(word *C.char) *C.char {
+	if !ready() {
+		return nil
+	}
+	return analysesJSON(morph.Parse(C.GoString(word)))
+}
+
+//export morph_xparse_json
+func morph_xparse_json(word *C.char) *C.char {
+	if !ready() {
+		return nil
+	}
+	return analysesJSON(morph.XParse(C.GoString(word)))
+}
+
+//export morph_lexeme_json
+func morph_lexeme_json(word, tag *C.char) *C.char {
+	if !ready() {
+		return nil
+	}
+	return analysesJSON(morph.Lexeme(C.GoString(word), C.GoString(tag)))
+}
+
+//export morph_inflect
+func morph_inflect(word, tag, grammemes *C.char) *C.char {
+	if !ready() {
+		return nil
+	}
+	var gs []string
+	if s := C.GoString(grammemes); s != "" {
+		gs = strings.Split(s, ",")
+	}
+	form, _, ok := morph.Inflect(C.GoString(word), C.GoString(tag), gs...)
+	if !ok {
+		return nil
+	}
+	return C.CString(form)
+}
+
+//export morph_free
+func morph_free(p unsafe.Pointer) {
+	C.free(p)
+}
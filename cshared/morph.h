@@ -0,0 +1,97 @@
+/*
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but
+ * WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+ * Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along
+ * with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+/*
+ * C interface to the morph package.
+ *
+ * Build the library with
+ *
+ *     go build -buildmode=c-shared -o libmorph.so
+ *
+ * in the cshared directory. The strings are UTF-8; the words passed to
+ * the analysis functions must be lowercase. The strings returned by
+ * the library must be freed with morph_free, the analyses with
+ * morph_free_analyses. After morph_init succeeds, the functions are safe
+ * to call from several threads.
+ */
+
+#ifndef MORPH_H
+#define MORPH_H
+
+#include <stddef.h>
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+typedef struct {
+	char *word; /* the word with the letter ё fixed */
+	char *norm; /* the normal form */
+	char *tag;  /* the OpenCorpora tag */
+} morph_analysis;
+
+typedef struct {
+	size_t len;
+	morph_analysis *items; /* sorted by probability, the most probable first */
+} morph_analyses;
+
+/* the declarations are skipped when the header is included by cgo,
+   which generates its own ones */
+#ifndef MORPH_NO_PROTOTYPES
+
+/*
+ * morph_init loads the pymorphy2 dictionary from dir or, if dir is NULL,
+ * from the dictionary package found using python. It returns NULL on
+ * success or the error message.
+ */
+char *morph_init(const char *dir);
+
+/*
+ * morph_parse and morph_xparse return the analyses of the word as Parse
+ * and XParse do; NULL is returned if the library is not initialized.
+ */
+morph_analyses *morph_parse(const char *word);
+morph_analyses *morph_xparse(const char *word);
+void morph_free_analyses(morph_analyses *a);
+
+/*
+ * morph_parse_json and morph_xparse_json return the analyses as a JSON
+ * array of the objects {"word": ..., "norm": ..., "tag": ...}.
+ */
+char *morph_parse_json(const char *word);
+char *morph_xparse_json(const char *word);
+
+/*
+ * morph_lexeme_json returns all the forms of the lexeme of the word with
+ * the tag (the most probable one if tag is NULL or empty) as a JSON array
+ * in the same format.
+ */
+char *morph_lexeme_json(const char *word, const char *tag);
+
+/*
+ * morph_inflect returns the form of the word with the comma-separated
+ * grammemes, e.g. "plur,datv", or NULL if there is no such form.
+ */
+char *morph_inflect(const char *word, const char *tag, const char *grammemes);
+
+void morph_free(void *p);
+
+#endif /* MORPH_NO_PROTOTYPES */
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif /* MORPH_H */
@@ -0,0 +1,94 @@
+/*
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but
+ * WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+ * Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along
+ * with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+/*
+ * A test program for libmorph. Build and run it with
+ *
+ *     make test DICT=/path/to/dictionary
+ *
+ * in the cshared directory; if DICT is empty, the dictionary is found
+ * using python.
+ */
+
+#include <stdio.h>
+#include <string.h>
+
+#include "../morph.h"
+
+static int failures;
+
+static void check(int ok, const char *what)
+{
+	if (!ok) {
+		fprintf(stderr, "FAIL: %s\n", what);
+		failures++;
+	}
+}
+
+int main(int argc, char **argv)
+{
+	char *err, *s;
+	morph_analyses *a;
+	size_t i;
+
+	check(morph_parse("стали") == NULL, "morph_parse before morph_init returns NULL");
+
+	err = morph_init(argc > 1 && argv[1][0] != '\0' ? argv[1] : NULL);
+	if (err != NULL) {
+		fprintf(stderr, "morph_init: %s\n", err);
+		morph_free(err);
+		return 1;
+	}
+
+	a = morph_parse("стали");
+	check(a != NULL && a->len > 0, "morph_parse(\"стали\") returns analyses");
+	if (a != NULL) {
+		for (i = 0; i < a->len; i++)
+			printf("%s\t%s\t%s\n", a->items[i].word, a->items[i].norm, a->items[i].tag);
+	}
+	morph_free_analyses(a);
+
+	a = morph_parse("абырвалгщ");
+	check(a != NULL && a->len == 0, "morph_parse of an unknown word returns no analyses");
+	morph_free_analyses(a);
+
+	a = morph_xparse("котами");
+	check(a != NULL && a->len > 0, "morph_xparse(\"котами\") returns analyses");
+	morph_free_analyses(a);
+
+	s = morph_parse_json("мама");
+	check(s != NULL && strstr(s, "\"norm\":\"мама\"") != NULL, "morph_parse_json(\"мама\")");
+	if (s != NULL)
+		printf("%s\n", s);
+	morph_free(s);
+
+	s = morph_lexeme_json("маму", NULL);
+	check(s != NULL && strstr(s, "\"word\":\"мамы\"") != NULL, "morph_lexeme_json(\"маму\")");
+	morph_free(s);
+
+	s = morph_inflect("ёж", NULL, "plur,datv");
+	check(s != NULL && strcmp(s, "ежам") == 0, "morph_inflect(\"ёж\", \"plur,datv\")");
+	morph_free(s);
+
+	s = morph_inflect("ёж", NULL, "bogus");
+	check(s == NULL, "morph_inflect with an unknown grammeme returns NULL");
+
+	if (failures > 0) {
+		fprintf(stderr, "%d failures\n", failures);
+		return 1;
+	}
+	printf("ok\n");
+	return 0;
+}
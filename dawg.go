@@ -49,64 +49,92 @@ func newDAWG(fn string) (*dawg, error) {
 	}, nil
 }
 
-func b64d(p []byte) []byte {
+// payloadBufLen is the size of the buffer decodePayload needs for the payloads
+// of the pymorphy2 dawgs: both the 4-byte payloads of the words dawg and the
+// 6-byte ones of the prediction dawgs take 8 base64 characters, which
+// base64.StdEncoding.DecodedLen reports as 6 bytes.
+const payloadBufLen = 6
+
+// decodePayload decodes the base64-encoded payload src into dst,
+// allocating a new buffer only if dst is too small.
+func decodePayload(dst, src []byte) []byte {
 	enc := base64.StdEncoding
-	dst := make([]byte, enc.DecodedLen(len(p)))
-	n, err := enc.Decode(dst, p)
+	if n := enc.DecodedLen(len(src)); n > len(dst) {
+		dst = make([]byte, n)
+	}
+	n, err := enc.Decode(dst, src)
 	if err != nil {
 		panic(err)
 	}
 	return dst[:n]
 }
 
-func (d *dawg) valuesForIndex(index uint32) [][]byte {
-	var values [][]byte
-	completer := newCompleter(d.Dict, d.Guide)
-	completer.start(index, "")
-	for completer.next() {
-		values = append(values, b64d(completer.key))
-	}
-	return values
-}
-
-type item struct {
-	key    string
-	values [][]byte
+// similarKey is a key found by similarKeys along with the index
+// of the node where its payloads start.
+type similarKey struct {
+	key   string
+	index uint32
 }
 
-func (d *dawg) similarItemsRecursive(prefix string, key []rune, index uint32) []item {
-	var items []item
-
-	startPos := utf8.RuneCountInString(prefix)
-	endPos := len(key)
-	wordPos := startPos
-
-	for wordPos < endPos {
-		r := key[wordPos]
-		if r == 'е' {
+// similarKeysRecursive looks up key[pos:] starting from the given index.
+// The prefix holds key[:pos] with some of the letters е replaced with ё,
+// or nil if there were no replacements. If whole is false, the found keys
+// are the prefixes of the dawg keys, and the index is their node.
+func (d *dawg) similarKeysRecursive(dst []similarKey, prefix []byte, key string, pos int, index uint32, whole bool) []similarKey {
+	type branch struct {
+		pos   int
+		index uint32
+	}
+	var buf [4]branch
+	branches := buf[:0]
+
+	startPos := pos
+	for pos < len(key) {
+		_, size := utf8.DecodeRuneInString(key[pos:])
+		r := key[pos : pos+size]
+		if r == "е" {
 			if next := d.Dict.follow("ё", index); next != 0 {
-				newPrefix := prefix + string(key[startPos:wordPos]) + "ё"
-				items = append(items,
-					d.similarItemsRecursive(newPrefix, key, next)...,
-				)
+				branches = append(branches, branch{pos, next})
 			}
 		}
-		if index = d.Dict.followRune(r, index); index == 0 {
+		if index = d.Dict.follow(r, index); index == 0 {
 			break
 		}
-		wordPos++
+		pos += size
 	}
-	if wordPos == endPos {
-		if index = d.Dict.followByte(payloadSeparator, index); index != 0 {
-			foundKey := prefix + string(key[startPos:])
-			value := d.valuesForIndex(index)
-			items = append([]item{{foundKey, value}}, items...)
+	if pos == len(key) {
+		if whole {
+			index = d.Dict.followByte(payloadSeparator, index)
 		}
+		// the root has index 0 and is found only as the empty prefix
+		if index != 0 || !whole {
+			foundKey := key
+			if prefix != nil {
+				foundKey = string(prefix) + key[startPos:]
+			}
+			dst = append(dst, similarKey{foundKey, index})
+		}
+	}
+
+	for _, b := range branches {
+		newPrefix := append(prefix[:len(prefix):len(prefix)], key[startPos:b.pos]...)
+		newPrefix = append(newPrefix, "ё"...)
+		dst = d.similarKeysRecursive(dst, newPrefix, key, b.pos+len("е"), b.index, whole)
 	}
 
-	return items
+	return dst
+}
+
+// similarKeys appends to dst the keys of the dawg that are equal to key
+// with some (or none) of the letters е replaced with ё.
+// The exact match, if any, goes first.
+func (d *dawg) similarKeys(dst []similarKey, key string) []similarKey {
+	return d.similarKeysRecursive(dst, nil, key, 0, 0, true)
 }
 
-func (d *dawg) similarItems(key string) []item {
-	return d.similarItemsRecursive("", []rune(key), 0)
+// similarPrefixes appends to dst the prefixes of the dawg keys that are
+// equal to prefix with some (or none) of the letters е replaced with ё,
+// along with their nodes. The exact match, if any, goes first.
+func (d *dawg) similarPrefixes(dst []similarKey, prefix string) []similarKey {
+	return d.similarKeysRecursive(dst, nil, prefix, 0, 0, false)
 }
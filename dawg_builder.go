@@ -0,0 +1,390 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+)
+
+// The builder produces the same on-disk format as dawgdic
+// (the library behind the DAWG python package used by pymorphy2):
+// a double-array dictionary followed by a guide.
+
+const (
+	offsetMax  = 1 << 21
+	upperMask  = ^uint32(offsetMax - 1)
+	lowerMask  = 0xff
+	blockSize  = 256
+	openBlocks = 16
+)
+
+var (
+	errUnsortedKeys = errors.New("dawg: keys must be added in the strictly increasing order")
+	errInvalidKey   = errors.New("dawg: keys must not contain zero bytes")
+	errValueRange   = errors.New("dawg: value out of range")
+	errTooLarge     = errors.New("dawg: too many keys")
+)
+
+type dawgEdge struct {
+	label byte
+	child int32
+}
+
+type dawgNode struct {
+	edges []dawgEdge // sorted by label; label 0 leads to a leaf
+	value uint32     // for leaves
+}
+
+// dawgBuilder builds a minimal dawg from the keys added in the
+// lexicographic order (Daciuk's incremental algorithm).
+type dawgBuilder struct {
+	nodes     []dawgNode
+	registry  map[string]int32
+	unchecked []int32 // path of the last key, starting from the root
+	lastKey   string
+	hasKeys   bool
+}
+
+func newDAWGBuilder() *dawgBuilder {
+	return &dawgBuilder{
+		nodes:     []dawgNode{{}},
+		registry:  make(map[string]int32),
+		unchecked: []int32{0},
+	}
+}
+
+func (b *dawgBuilder) signature(id int32) string {
+	n := &b.nodes[id]
+	var sb strings.Builder
+	var buf [4]byte
+	if len(n.edges) == 0 {
+		sb.WriteByte('v')
+		binary.LittleEndian.PutUint32(buf[:], n.value)
+		sb.Write(buf[:])
+		return sb.String()
+	}
+	for _, e := range n.edges {
+		sb.WriteByte(e.label)
+		binary.LittleEndian.PutUint32(buf[:], uint32(e.child))
+		sb.Write(buf[:])
+	}
+	return sb.String()
+}
+
+// minimize replaces the nodes of the unchecked path below depth
+// with their registered equivalents.
+func (b *dawgBuilder) minimize(depth int) {
+	for len(b.unchecked)-1 > depth {
+		child := b.unchecked[len(b.unchecked)-1]
+		b.unchecked = b.unchecked[:len(b.unchecked)-1]
+		parent := &b.nodes[b.unchecked[len(b.unchecked)-1]]
+		sig := b.signature(child)
+		if id, ok := b.registry[sig]; ok {
+			// the replaced node becomes unreachable and is never converted
+			parent.edges[len(parent.edges)-1].child = id
+			continue
+		}
+		b.registry[sig] = child
+	}
+}
+
+// add adds the key with the value; the keys must be added in the
+// strictly increasing order.
+func (b *dawgBuilder) add(key string, value uint32) error {
+	if strings.IndexByte(key, 0) != -1 {
+		return errInvalidKey
+	}
+	if value >= isLeafBit {
+		return errValueRange
+	}
+	if b.hasKeys && key <= b.lastKey {
+		return errUnsortedKeys
+	}
+
+	common := 0
+	if b.hasKeys {
+		for common < len(key) && common < len(b.lastKey) && key[common] == b.lastKey[common] {
+			common++
+		}
+		// the previous key ends with the terminator, which is never shared
+	}
+	b.minimize(common)
+
+	k := key + "\x00"
+	for i := common; i < len(k); i++ {
+		id := int32(len(b.nodes))
+		b.nodes = append(b.nodes, dawgNode{})
+		parent := &b.nodes[b.unchecked[len(b.unchecked)-1]]
+		parent.edges = append(parent.edges, dawgEdge{k[i], id})
+		b.unchecked = append(b.unchecked, id)
+	}
+	b.nodes[b.unchecked[len(b.unchecked)-1]].value = value
+
+	b.lastKey = key
+	b.hasKeys = true
+	return nil
+}
+
+// finish minimizes the rest of the dawg and converts it
+// into a dictionary with a guide.
+func (b *dawgBuilder) finish() (*dawg, error) {
+	b.minimize(0)
+	b.registry = nil
+
+	db := dictionaryBuilder{
+		nodes: b.nodes,
+		bases: make([]uint32, len(b.nodes)),
+	}
+	if err := db.build(); err != nil {
+		return nil, err
+	}
+	d := dictionary(db.units)
+
+	g := make(guide, len(d)*2)
+	visited := make([]bool, len(d))
+	if err := buildGuide(b.nodes, d, g, visited, 0, 0); err != nil {
+		return nil, err
+	}
+
+	return &dawg{Dict: d, Guide: g}, nil
+}
+
+type dictionaryBuilder struct {
+	nodes     []dawgNode
+	units     []uint32
+	used      []bool  // unit is occupied
+	usedBase  []bool  // base (offset) is occupied
+	next      []int32 // free list over the open blocks
+	prev      []int32
+	head      int32    // first free unit or -1
+	openStart int      // first open block
+	bases     []uint32 // node -> base of its children; 0 if not arranged yet
+}
+
+func (db *dictionaryBuilder) build() error {
+	db.head = -1
+	db.addBlock()
+	db.reserve(0)
+	db.usedBase[0] = true
+	db.units[0] = setOffset(0, 1)
+	if len(db.nodes[0].edges) == 0 {
+		return nil
+	}
+	return db.buildNode(0, 0)
+}
+
+func (db *dictionaryBuilder) addBlock() {
+	start := len(db.units)
+	db.units = append(db.units, make([]uint32, blockSize)...)
+	db.used = append(db.used, make([]bool, blockSize)...)
+	db.usedBase = append(db.usedBase, make([]bool, blockSize)...)
+	db.next = append(db.next, make([]int32, blockSize)...)
+	db.prev = append(db.prev, make([]int32, blockSize)...)
+	for i := start; i < start+blockSize; i++ {
+		db.linkFree(int32(i))
+	}
+	if (len(db.units)/blockSize)-db.openStart > openBlocks {
+		db.closeBlock()
+	}
+}
+
+// linkFree appends the unit to the end of the free list.
+func (db *dictionaryBuilder) linkFree(i int32) {
+	if db.head == -1 {
+		db.head = i
+		db.next[i], db.prev[i] = i, i
+		return
+	}
+	last := db.prev[db.head]
+	db.next[last] = i
+	db.prev[i] = last
+	db.next[i] = db.head
+	db.prev[db.head] = i
+}
+
+func (db *dictionaryBuilder) unlinkFree(i int32) {
+	if db.next[i] == i {
+		db.head = -1
+		return
+	}
+	db.next[db.prev[i]] = db.next[i]
+	db.prev[db.next[i]] = db.prev[i]
+	if db.head == i {
+		db.head = db.next[i]
+	}
+}
+
+// closeBlock removes the free units of the oldest open block from the
+// free list, so that the search for offsets stays fast; they remain unused.
+func (db *dictionaryBuilder) closeBlock() {
+	start := db.openStart * blockSize
+	for i := start; i < start+blockSize; i++ {
+		if !db.used[i] {
+			db.used[i] = true
+			db.unlinkFree(int32(i))
+		}
+	}
+	db.openStart++
+}
+
+func (db *dictionaryBuilder) reserve(i uint32) {
+	for int(i) >= len(db.units) {
+		db.addBlock()
+	}
+	if db.used[i] {
+		panic("dawg: unit is already used")
+	}
+	db.used[i] = true
+	db.unlinkFree(int32(i))
+}
+
+func encodable(off uint32) bool {
+	return off < 1<<29 && (off&upperMask == 0 || off&lowerMask == 0)
+}
+
+func (db *dictionaryBuilder) goodOffset(index uint32, labels []byte) uint32 {
+	if db.head != -1 {
+		i := db.head
+		for {
+			base := uint32(i) ^ uint32(labels[0])
+			if db.fits(index, base, labels) {
+				return base
+			}
+			i = db.next[i]
+			if i == db.head {
+				break
+			}
+		}
+	}
+	// fresh block: choose the base so that its relative offset is encodable
+	db.addBlock()
+	return uint32(len(db.units)-blockSize) | (index & lowerMask)
+}
+
+func (db *dictionaryBuilder) fits(index, base uint32, labels []byte) bool {
+	if int(base) >= len(db.units) || db.usedBase[base] || !encodable(index^base) {
+		return false
+	}
+	for _, l := range labels[1:] {
+		if db.used[base^uint32(l)] {
+			return false
+		}
+	}
+	return true
+}
+
+func (db *dictionaryBuilder) buildNode(id int32, index uint32) error {
+	n := &db.nodes[id]
+	if len(n.edges) == 0 {
+		return nil
+	}
+
+	// reuse the children arranged for a merged node, if possible
+	if base := db.bases[id]; base != 0 {
+		if off := index ^ base; encodable(off) {
+			if n.edges[0].label == 0 {
+				db.units[index] |= hasLeafBit
+			}
+			db.units[index] = setOffset(db.units[index], off)
+			return nil
+		}
+	}
+
+	labels := make([]byte, len(n.edges))
+	for i, e := range n.edges {
+		labels[i] = e.label
+	}
+	base := db.goodOffset(index, labels)
+	if base >= 1<<29 {
+		return errTooLarge
+	}
+	db.units[index] = setOffset(db.units[index], index^base)
+	db.usedBase[base] = true
+	for _, e := range n.edges {
+		child := base ^ uint32(e.label)
+		db.reserve(child)
+		if e.label == 0 {
+			db.units[index] |= hasLeafBit
+			db.units[child] = db.nodes[e.child].value | isLeafBit
+		} else {
+			db.units[child] = uint32(e.label)
+		}
+	}
+	db.bases[id] = base
+
+	for _, e := range n.edges {
+		if e.label == 0 {
+			continue
+		}
+		if err := db.buildNode(e.child, base^uint32(e.label)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setOffset(unit, off uint32) uint32 {
+	unit &= isLeafBit | hasLeafBit | 0xff
+	if off < offsetMax {
+		return unit | off<<10
+	}
+	return unit | off<<2 | extensionBit
+}
+
+func buildGuide(nodes []dawgNode, d dictionary, g guide, visited []bool, id int32, index uint32) error {
+	if visited[index] {
+		return nil
+	}
+	visited[index] = true
+
+	edges := nodes[id].edges
+	if len(edges) > 0 && edges[0].label == 0 {
+		edges = edges[1:]
+	}
+	if len(edges) == 0 {
+		return nil
+	}
+	g[index*2] = edges[0].label
+	for i, e := range edges {
+		child := d.followByte(e.label, index)
+		if child == 0 {
+			return errors.New("dawg: broken dictionary")
+		}
+		if err := buildGuide(nodes, d, g, visited, e.child, child); err != nil {
+			return err
+		}
+		if i+1 < len(edges) {
+			g[child*2+1] = edges[i+1].label
+		}
+	}
+	return nil
+}
+
+// writeTo writes the dawg in the format read by newDAWG.
+func (d *dawg) writeTo(w io.Writer) error {
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(d.Dict))); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, []uint32(d.Dict)); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(d.Guide)/2)); err != nil {
+		return err
+	}
+	_, err := w.Write(d.Guide)
+	return err
+}
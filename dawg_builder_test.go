@@ -0,0 +1,111 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"testing"
+)
+
+func buildTestDAWG(t testing.TB, kv map[string]uint32) *dawg {
+	var keys []string
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b := newDAWGBuilder()
+	for _, k := range keys {
+		if err := b.add(k, kv[k]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d, err := b.finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// round-trip through the on-disk format
+	var buf bytes.Buffer
+	if err := d.writeTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	dict, err := newDictionary(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := newGuide(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &dawg{dict, g}
+}
+
+func TestDAWGBuilder(t *testing.T) {
+	kv := make(map[string]uint32)
+	for i := 0; i < 20000; i++ {
+		kv[fmt.Sprintf("ключ%d:%d", i%777, i)] = uint32(i)
+	}
+	kv["ключ1"] = 1 << 30
+	d := buildTestDAWG(t, kv)
+
+	for k, v := range kv {
+		if got := d.Dict.find(k); got != v {
+			t.Fatalf("find(%q): want %d, got %d", k, v, got)
+		}
+	}
+	for _, k := range []string{"ключ", "ключ1:", "ключ20000", "нет"} {
+		if got := d.Dict.find(k); got != 0 {
+			t.Errorf("find(%q): want 0, got %d", k, got)
+		}
+	}
+
+	var c completer
+	c.init(d.Dict, d.Guide)
+	c.start(0, "")
+	n := 0
+	prev := ""
+	for c.next() {
+		k := string(c.key)
+		if _, ok := kv[k]; !ok {
+			t.Fatalf("completer: unexpected key %q", k)
+		}
+		if k <= prev {
+			t.Fatalf("completer: want the keys in the increasing order, got %q after %q", k, prev)
+		}
+		prev = k
+		n++
+	}
+	if n != len(kv) {
+		t.Errorf("completer: want %d keys, got %d", len(kv), n)
+	}
+}
+
+func TestDAWGBuilderErrors(t *testing.T) {
+	b := newDAWGBuilder()
+	if err := b.add("b", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.add("a", 1); err != errUnsortedKeys {
+		t.Errorf("add: want %v, got %v", errUnsortedKeys, err)
+	}
+	if err := b.add("c\x00", 1); err != errInvalidKey {
+		t.Errorf("add: want %v, got %v", errInvalidKey, err)
+	}
+	if err := b.add("d", 1<<31); err != errValueRange {
+		t.Errorf("add: want %v, got %v", errValueRange, err)
+	}
+}
@@ -69,7 +69,7 @@ func (d dictionary) value(index uint32) uint32 {
 
 func (d dictionary) find(key string) uint32 {
 	index := d.follow(key, 0)
-	if index == 0 {
+	if index == 0 || !d.hasValue(index) {
 		return 0
 	}
 	return d.value(index)
@@ -95,8 +95,8 @@ func (d dictionary) follow(s string, index uint32) uint32 {
 }
 
 func (d dictionary) followRune(r rune, index uint32) uint32 {
-	buf := make([]byte, 4)
-	n := utf8.EncodeRune(buf, r)
+	var buf [utf8.UTFMax]byte
+	n := utf8.EncodeRune(buf[:], r)
 	for i := 0; i < n; i++ {
 		index = d.followByte(buf[i], index)
 		if index == 0 {
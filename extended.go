@@ -15,7 +15,6 @@ package morph
 
 import (
 	"encoding/binary"
-	"regexp"
 	"sort"
 	"strings"
 	"unicode/utf8"
@@ -179,6 +178,14 @@ var knownPrefixes = []string{
 	"этно",
 }
 
+// the categories whose values are compared (besides the parts of speech)
+// when analyzing the hyphenated words: numbers, cases, persons and tenses
+var featureCategories = []string{"NMbr", caseCategory, "PErs", "TEns"}
+
+// grammemes that are compared as their general counterparts (their parents)
+// when analyzing the hyphenated words
+var featureParentAliases = []string{"gen1", "loc1"}
+
 var nonproductiveGrammemes = []string{
 	"NUMR",
 	"NPRO",
@@ -200,13 +207,8 @@ func init() {
 	})
 }
 
-func productive(tag string) bool {
-	for _, g := range nonproductiveGrammemes {
-		if strings.Contains(tag, g) {
-			return false
-		}
-	}
-	return true
+func productive(s GrammemeSet) bool {
+	return !s.Intersects(nonproductiveSet)
 }
 
 func min(a, b int) int {
@@ -246,41 +248,16 @@ func split5(s string) [][2]string {
 	return splits
 }
 
-func emptyUnlessFeature(s string) string {
-	switch s {
-	case "loc1":
-		return "loct"
-	case "gen1":
-		return "gent"
-
-		// parts of speech
-	case "NOUN", "ADJF", "ADJS", "COMP", "VERB", "INFN", "PRTF", "PRTS", "GRND", "NUMR", "ADVB", "NPRO", "PRED", "PREP", "CONJ", "PRCL", "INTJ":
-		fallthrough
-
-		// numbers
-	case "sing", "plur":
-		fallthrough
-
-		// cases
-	case "nomn", "gent", "datv", "accs", "ablt", "loct", "voct", "gen2", "acc2", "loc2":
-		fallthrough
-
-		// persons
-	case "1per", "2per", "3per":
-		fallthrough
-
-		// tenses
-	case "pres", "past", "futr":
-		return s
-
+// similarityFeatures returns the grammemes of the tag that are compared
+// when analyzing the hyphenated words.
+func similarityFeatures(t Tag) GrammemeSet {
+	s := t.set.intersect(featureSet)
+	for _, a := range featureAliases {
+		if t.set.Has(a.from) {
+			s.Add(a.to)
+		}
 	}
-	return ""
-}
-
-var rFeature = regexp.MustCompile(`[^ ,]+`)
-
-func similarityFeatures(tag string) string {
-	return rFeature.ReplaceAllStringFunc(tag, emptyUnlessFeature)
+	return s
 }
 
 // XParse analyzes the word (which might not be in the dictionary)
@@ -292,12 +269,39 @@ func similarityFeatures(tag string) string {
 // If the word is in the dictionary, XParse is equivalent to Parse.
 // Otherwise it tries several other analyzers to analyze the unknown word.
 func XParse(word string) (words, norms, tags []string) {
+	if probDAWG == nil {
+		panic("not initialized; call Init or InitWith")
+	}
+	words, norms, ids := xparse(word)
+	return words, norms, externalTags(tagNames(ids))
+}
+
+// xparse is XParse returning the indices of the tags in tags
+// instead of the tags.
+func xparse(word string) (words, norms []string, ids []int) {
 	word = strings.ToLower(word)
-	words, norms, tags = Parse(word)
+	words, norms, ids = parse(word)
 	if len(words) > 0 {
-		return words, norms, tags
+		return words, norms, ids
 	}
 
+	c := predictCache
+	if c != nil {
+		if words, norms, ids, ok := c.get(word); ok {
+			return words, norms, ids
+		}
+	}
+
+	words, norms, ids = predict(word)
+	if c != nil {
+		c.add(word, words, norms, ids)
+	}
+	return words, norms, ids
+}
+
+// predict analyzes the (lowercase) word that is not in the dictionary.
+// As parse, it returns the indices of the tags in tags.
+func predict(word string) (words, norms []string, ids []int) {
 	containsHyphen := strings.IndexByte(word, '-') != -1
 
 	// try to strip a particle after the hyphen, e.g. смотри-ка -> смотри + ка
@@ -308,13 +312,13 @@ func XParse(word string) (words, norms, tags []string) {
 				continue
 			}
 			unsuffixed := strings.TrimSuffix(word, suffix)
-			words, norms, tags := XParse(unsuffixed)
+			words, norms, ids := xparse(unsuffixed)
 			if len(words) > 0 {
 				for i := range words {
 					words[i] += suffix
 					norms[i] += suffix
 				}
-				return words, norms, tags
+				return words, norms, ids
 			}
 		}
 	}
@@ -324,14 +328,15 @@ func XParse(word string) (words, norms, tags []string) {
 	// parse adverbs starting with по-, e.g. по-западному
 	// (HyphenAdverbAnalyzer in pymorphy2)
 	if nRunes >= 5 && strings.HasPrefix(word, "по-") {
-		words, _, tags := XParse(word[5:])
-		for i, tag := range tags {
-			if !strings.HasPrefix(tag, "ADJF") ||
-				!strings.Contains(tag, "sing,datv") {
+		words, _, ids := xparse(word[5:])
+		adjSingDatv := grammemeSet("ADJF", "sing", "datv")
+		advb, ok := tagIndex["ADVB"]
+		for i, id := range ids {
+			if !ok || !tagSets[id].HasAll(adjSingDatv) {
 				continue
 			}
 			w := "по-" + words[i]
-			return []string{w}, []string{w}, []string{"ADVB"}
+			return []string{w}, []string{w}, []int{advb}
 		}
 	}
 
@@ -345,18 +350,18 @@ func XParse(word string) (words, norms, tags []string) {
 		if utf8.RuneCountInString(unprefixed) < 3 {
 			continue
 		}
-		ws, ns, ts := XParse(unprefixed)
-		for i, tag := range ts {
-			if !productive(tag) {
+		ws, ns, is := xparse(unprefixed)
+		for i, id := range is {
+			if !productive(tagSets[id]) {
 				continue
 			}
 			words = append(words, prefix+ws[i])
 			norms = append(norms, prefix+ns[i])
-			tags = append(tags, ts[i])
+			ids = append(ids, id)
 		}
 	}
 	if len(words) > 0 {
-		return words, norms, tags
+		return words, norms, ids
 	}
 
 	// parse word by parsing its hyphen-separated parts, e.g.
@@ -368,30 +373,30 @@ func XParse(word string) (words, norms, tags []string) {
 
 		parts := strings.SplitN(word, "-", 2)
 		left, right := parts[0], parts[1]
-		lwords, lnorms, ltags := XParse(left)
-		rwords, rnorms, rtags := XParse(right)
-		rightFeatures := make([]string, len(rtags))
-		for i, tag := range rtags {
-			rightFeatures[i] = similarityFeatures(tag)
+		lwords, lnorms, lids := xparse(left)
+		rwords, rnorms, rids := xparse(right)
+		rightFeatures := make([]GrammemeSet, len(rids))
+		for i, id := range rids {
+			rightFeatures[i] = similarityFeatures(Tag{tagSets[id], tags[id]})
 		}
-		for i, tag := range ltags {
-			leftFeat := similarityFeatures(tag)
-			for j := range rtags {
+		for i, id := range lids {
+			leftFeat := similarityFeatures(Tag{tagSets[id], tags[id]})
+			for j := range rids {
 				if leftFeat != rightFeatures[j] {
 					continue
 				}
 				words = append(words, lwords[i]+"-"+rwords[j])
 				norms = append(norms, lnorms[i]+"-"+rnorms[j])
-				tags = append(tags, tag)
+				ids = append(ids, id)
 			}
 		}
-		for i, tag := range rtags {
+		for i, id := range rids {
 			words = append(words, left+"-"+rwords[i])
 			norms = append(norms, left+"-"+rnorms[i])
-			tags = append(tags, tag)
+			ids = append(ids, id)
 		}
 		if len(words) > 0 {
-			return words, norms, tags
+			return words, norms, ids
 		}
 	}
 
@@ -400,14 +405,14 @@ func XParse(word string) (words, norms, tags []string) {
 	// (UnknownPrefixAnalyzer in pymorphy2)
 	for _, split := range wordSplits(word, 3, 5) {
 		prefix, unprefixed := split[0], split[1]
-		ws, ns, ts := Parse(unprefixed)
-		for i, tag := range ts {
-			if !productive(tag) {
+		ws, ns, is := parse(unprefixed)
+		for i, id := range is {
+			if !productive(tagSets[id]) {
 				continue
 			}
 			words = append(words, prefix+ws[i])
 			norms = append(norms, prefix+ns[i])
-			tags = append(tags, ts[i])
+			ids = append(ids, id)
 		}
 	}
 
@@ -416,31 +421,38 @@ func XParse(word string) (words, norms, tags []string) {
 	// (KnownSuffixAnalyzer in pymorphy2)
 	if nRunes >= 4 {
 		splits := split5(word)
+		var c completer
+		var keyBuf [4]similarKey
+		var valueBuf [payloadBufLen]byte
 		for id, prefix := range prefixes {
 			if !strings.HasPrefix(word, prefix) {
 				continue
 			}
 			totalCount := 0
 			dawg := predictionDAWGs[id]
+			c.init(dawg.Dict, dawg.Guide)
 			for i := len(splits) - 1; i >= 0; i-- {
 				sp := splits[i]
 				wordStart, wordEnd := sp[0], sp[1]
 			sloop:
-				for _, it := range dawg.similarItems(wordEnd) {
-					for _, v := range it.values {
+				for _, sk := range dawg.similarKeys(keyBuf[:0], wordEnd) {
+					c.start(sk.index, "")
+					for c.next() {
+						v := decodePayload(valueBuf[:], c.key)
 						count := int(binary.BigEndian.Uint16(v))
 						paraNum := int(binary.BigEndian.Uint16(v[2:]))
 						para := paradigms[paraNum]
 						index := int(binary.BigEndian.Uint16(v[4:]))
 
-						prefix, suffix, tag := prefixSuffixTag(para, index)
-						if !productive(tag) {
+						prefix, suffix, _ := prefixSuffixTag(para, index)
+						tid := paradigmTag(para, index)
+						if !productive(tagSets[tid]) {
 							continue
 						}
 
 						totalCount += count
 
-						word := wordStart + it.key
+						word := wordStart + sk.key
 						norm := word
 						if index != 0 {
 							stem := strings.TrimPrefix(norm, prefix)
@@ -449,15 +461,15 @@ func XParse(word string) (words, norms, tags []string) {
 							norm = pr + stem + su
 						}
 
-						for i, t := range tags {
-							if t == tag && words[i] == word && norms[i] == norm {
+						for i, t := range ids {
+							if t == tid && words[i] == word && norms[i] == norm {
 								continue sloop
 							}
 						}
 
 						words = append(words, word)
 						norms = append(norms, norm)
-						tags = append(tags, tag)
+						ids = append(ids, tid)
 					}
 				}
 				if totalCount > 1 {
@@ -467,5 +479,5 @@ func XParse(word string) (words, norms, tags []string) {
 		}
 	}
 
-	return words, norms, tags
+	return words, norms, ids
 }
@@ -222,6 +222,20 @@ func TestXParse(t *testing.T) {
 	}
 }
 
+// The parts of a hyphenated word are matched by the sets of their parts
+// of speech, numbers, cases, persons and tenses only, so the other grammemes
+// do not matter even if the parts have different numbers of them, e.g.
+// the name Петя (with Name) matches робот.
+func TestXParseHyphenatedFeatures(t *testing.T) {
+	words, norms, tags := XParse("петя-робот")
+	for i := range words {
+		if words[i] == "петя-робот" && norms[i] == "петя-робот" && tags[i] == "NOUN,anim,masc,Name sing,nomn" {
+			return
+		}
+	}
+	t.Errorf("XParse(петя-робот): want the analysis of the name петя, got %v %v %v", words, norms, tags)
+}
+
 func uniq(ss []string) []string {
 	res := ss[:0]
 outer:
@@ -0,0 +1,172 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"unicode"
+	"unicode/utf8"
+)
+
+// the categories of the parts of speech and the cases in the catalogue
+const (
+	posCategory  = "POST"
+	caseCategory = "CAse"
+)
+
+// grammemeEntries are the entries of grammemes.json: the name
+// of a grammeme, its parent, its Russian alias and description.
+var grammemeEntries [][]string
+
+// grammemeInfo is the catalogue entry of a grammeme.
+type grammemeInfo struct {
+	parent      int // the index of the parent in grammemeNames, or -1
+	alias       string
+	description string
+}
+
+var grammemeInfos []grammemeInfo // parallel to grammemeNames
+
+func loadGrammemes(fn string) error {
+	f, err := os.Open(fn)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var entries [][]string
+	if err := json.NewDecoder(f).Decode(&entries); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if len(e) == 0 || e[0] == "" {
+			return fmt.Errorf("%s: invalid grammeme entry %q", fn, e)
+		}
+	}
+	grammemeEntries = entries
+	return nil
+}
+
+// indexGrammemes fills in the catalogue entries of the grammemes
+// registered by indexTags.
+func indexGrammemes() {
+	grammemeInfos = make([]grammemeInfo, len(grammemeNames))
+	for i := range grammemeInfos {
+		grammemeInfos[i].parent = -1
+	}
+	field := func(e []string, i int) string {
+		if i < len(e) {
+			return e[i]
+		}
+		return ""
+	}
+	for _, e := range grammemeEntries {
+		info := &grammemeInfos[grammemeIndex[e[0]]]
+		if p, ok := grammemeIndex[field(e, 1)]; ok {
+			info.parent = int(p)
+		}
+		info.alias = field(e, 2)
+		info.description = field(e, 3)
+	}
+}
+
+// Grammemes returns all the grammemes known to the loaded dictionary:
+// the ones of its grammeme catalogue in the catalogue order, followed by
+// the ones found only in the tags (e.g. LATN or PNCT).
+func Grammemes() []Grammeme {
+	gs := make([]Grammeme, len(grammemeNames))
+	for i := range gs {
+		gs[i] = Grammeme(i)
+	}
+	return gs
+}
+
+// Parent returns the parent of the grammeme in the grammeme hierarchy,
+// e.g. CAse for nomn, nomn for voct and POST for NOUN.
+// It returns false if the grammeme has no parent.
+func (g Grammeme) Parent() (Grammeme, bool) {
+	if int(g) >= len(grammemeInfos) || grammemeInfos[g].parent < 0 {
+		return 0, false
+	}
+	return Grammeme(grammemeInfos[g].parent), true
+}
+
+// Category returns the root of the grammeme hierarchy the grammeme belongs
+// to, e.g. CAse for nomn and voct, or the grammeme itself if it has no parent.
+func (g Grammeme) Category() Grammeme {
+	// the number of the grammemes limits the walk in a malformed catalogue
+	for range grammemeInfos {
+		p, ok := g.Parent()
+		if !ok {
+			break
+		}
+		g = p
+	}
+	return g
+}
+
+// Alias returns the short Russian name of the grammeme, e.g. "им" for nomn,
+// as used by the external tag format.
+func (g Grammeme) Alias() string {
+	if int(g) < len(grammemeInfos) && grammemeInfos[g].alias != "" {
+		return grammemeInfos[g].alias
+	}
+	return grammemeToExt[grammemeNames[g]]
+}
+
+// Description returns the Russian description of the grammeme,
+// e.g. "именительный падеж" for nomn, or "" if the catalogue has none.
+func (g Grammeme) Description() string {
+	if int(g) < len(grammemeInfos) {
+		return grammemeInfos[g].description
+	}
+	return ""
+}
+
+// childrenOf returns the set of the grammemes with the named parent.
+func childrenOf(name string) GrammemeSet {
+	var s GrammemeSet
+	for i, info := range grammemeInfos {
+		if info.parent >= 0 && grammemeNames[info.parent] == name {
+			s.Add(Grammeme(i))
+		}
+	}
+	return s
+}
+
+// valuesOf returns the set of the values of the named category:
+// its descendants named in lower case, as OpenCorpora names
+// the inflectional grammemes, e.g. nomn, gen2 and voct for CAse.
+// This leaves out the lexical grammemes like Sgtm.
+func valuesOf(name string) GrammemeSet {
+	var s GrammemeSet
+	for i, info := range grammemeInfos {
+		if r, _ := utf8.DecodeRuneInString(grammemeNames[i]); !unicode.IsLower(r) && !unicode.IsDigit(r) {
+			continue
+		}
+		for range grammemeInfos {
+			if info.parent < 0 {
+				break
+			}
+			if grammemeNames[info.parent] == name {
+				s.Add(Grammeme(i))
+				break
+			}
+			info = grammemeInfos[info.parent]
+		}
+	}
+	return s
+}
@@ -0,0 +1,66 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import "testing"
+
+func TestGrammemeCatalogue(t *testing.T) {
+	lookup := func(name string) Grammeme {
+		g, ok := LookupGrammeme(name)
+		if !ok {
+			t.Fatalf("unknown grammeme: %s", name)
+		}
+		return g
+	}
+	tests := []struct {
+		name, parent, category, alias string
+	}{
+		{"NOUN", "POST", "POST", "СУЩ"},
+		{"nomn", "CAse", "CAse", "им"},
+		{"voct", "nomn", "CAse", "зв"},
+		{"gen2", "gent", "CAse", "рд2"},
+		{"masc", "GNdr", "GNdr", "мр"},
+		{"CAse", "", "CAse", "ПД"},
+	}
+	for _, tt := range tests {
+		g := lookup(tt.name)
+		parent := ""
+		if p, ok := g.Parent(); ok {
+			parent = p.String()
+		}
+		if parent != tt.parent || g.Category().String() != tt.category || g.Alias() != tt.alias {
+			t.Errorf("%s: want parent %q, category %s and alias %s, got %q, %s and %s",
+				tt.name, tt.parent, tt.category, tt.alias, parent, g.Category(), g.Alias())
+		}
+	}
+	if d := lookup("nomn").Description(); d != "именительный падеж" {
+		t.Errorf("nomn: want the description именительный падеж, got %q", d)
+	}
+
+	all := Grammemes()
+	if len(all) == 0 || all[0].String() != "POST" || all[len(all)-1] != Grammeme(len(all)-1) {
+		t.Errorf("Grammemes: want the catalogue order starting with POST, got %v", all)
+	}
+
+	for _, name := range []string{"NOUN", "nomn", "voct", "gen2", "loc2", "sing", "3per", "futr"} {
+		if !featureSet.Has(lookup(name)) {
+			t.Errorf("%s is not a similarity feature", name)
+		}
+	}
+	for _, name := range []string{"gen1", "loc1", "Sgtm", "masc", "anim", "CAse"} {
+		if featureSet.Has(lookup(name)) {
+			t.Errorf("%s is a similarity feature", name)
+		}
+	}
+}
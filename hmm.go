@@ -0,0 +1,375 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+)
+
+const (
+	hmmStart = "<s>"
+	hmmEnd   = "</s>"
+
+	// the share of the uniform distribution mixed into P(tag|word)
+	emissionSmoothing = 0.1
+	// the probability of a transition to a class never seen in training
+	unseenTransition = 1e-7
+)
+
+// HMM is a trigram hidden Markov model of the sequences of coarse tag classes
+// (the part of speech plus the case, if any), used to choose the analyses
+// of the words depending on their context.
+// The transition probabilities are interpolated with the bigram and
+// unigram ones; the emission probabilities are derived from P(tag|word)
+// of the dictionary.
+// The model is read-only and safe for concurrent use.
+type HMM struct {
+	Lambdas  [3]float64     `json:"lambdas"` // weights of the unigram, bigram and trigram probabilities
+	Unigrams map[string]int `json:"unigrams"`
+	Bigrams  map[string]int `json:"bigrams"` // keys are space-separated classes
+	Trigrams map[string]int `json:"trigrams"`
+	Total    int            `json:"total"`
+}
+
+// HMMTrainer collects the counts of the tag class n-grams from an annotated corpus.
+type HMMTrainer struct {
+	uni, bi, tri map[string]int
+	total        int
+}
+
+// NewHMMTrainer returns a new trainer.
+func NewHMMTrainer() *HMMTrainer {
+	return &HMMTrainer{
+		uni: make(map[string]int),
+		bi:  make(map[string]int),
+		tri: make(map[string]int),
+	}
+}
+
+// Add adds the tags of an annotated sentence to the counts.
+func (t *HMMTrainer) Add(tags []string) {
+	seq := make([]string, 0, len(tags)+3)
+	seq = append(seq, hmmStart, hmmStart)
+	for _, tag := range tags {
+		seq = append(seq, hmmClass(tag))
+	}
+	seq = append(seq, hmmEnd)
+
+	for i, c := range seq {
+		if i == 0 {
+			continue // count the start once
+		}
+		t.uni[c]++
+		t.total++
+		t.bi[seq[i-1]+" "+c]++
+		if i >= 2 {
+			t.tri[seq[i-2]+" "+seq[i-1]+" "+c]++
+		}
+	}
+}
+
+// AddTokens adds the tags of a sentence of an annotated corpus to the counts
+// and reports whether the sentence was added. A token without the Tag gets
+// its analysis matching the UD part of speech and features, and a token
+// that is not a word gets PNCT, NUMB, LATN or UNKN, as in Disambiguate.
+// The sentence is skipped if the tag of some token is still unknown.
+func (t *HMMTrainer) AddTokens(sentence []CorpusToken) bool {
+	tags := make([]string, len(sentence))
+	for i, tok := range sentence {
+		tag := tok.Tag
+		if tag == "" {
+			form := strings.ToLower(tok.Form)
+			_, _, ids := xparse(form)
+			switch {
+			case len(ids) == 0:
+				tag = fallbackTag(form)
+			case tok.UPOS != "":
+				tag = matchUD(tagNames(ids), tok.UPOS, tok.Feats)
+			}
+		}
+		if tag == "" {
+			return false
+		}
+		tags[i] = tag
+	}
+	t.Add(tags)
+	return true
+}
+
+// Model returns the model trained on the sentences added so far.
+func (t *HMMTrainer) Model() *HMM {
+	m := &HMM{
+		Unigrams: t.uni,
+		Bigrams:  t.bi,
+		Trigrams: t.tri,
+		Total:    t.total,
+	}
+
+	// deleted interpolation (Brants, 2000)
+	var l [3]float64
+	for k, f := range t.tri {
+		c := strings.SplitN(k, " ", 3)
+		ratio := func(num, den int) float64 {
+			if den <= 1 {
+				return 0
+			}
+			return float64(num-1) / float64(den-1)
+		}
+		r := [3]float64{
+			ratio(t.uni[c[2]], t.total),
+			ratio(t.bi[c[1]+" "+c[2]], t.uni[c[1]]),
+			ratio(f, t.bi[c[0]+" "+c[1]]),
+		}
+		best := 0
+		for i := range r {
+			if r[i] > r[best] {
+				best = i
+			}
+		}
+		l[best] += float64(f)
+	}
+	sum := l[0] + l[1] + l[2]
+	if sum == 0 {
+		l, sum = [3]float64{1, 1, 1}, 3
+	}
+	for i := range l {
+		m.Lambdas[i] = l[i] / sum
+	}
+
+	return m
+}
+
+// LoadHMM loads the model from the file.
+func LoadHMM(fn string) (*HMM, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var m HMM
+	if err := json.NewDecoder(f).Decode(&m); err != nil {
+		return nil, err
+	}
+	if m.Total == 0 {
+		return nil, errors.New("empty HMM model")
+	}
+	return &m, nil
+}
+
+// Save saves the model to the file.
+func (m *HMM) Save(fn string) error {
+	f, err := os.Create(fn)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(m); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (m *HMM) classProb(c string) float64 {
+	return (float64(m.Unigrams[c]) + 1) / float64(m.Total+1)
+}
+
+func (m *HMM) transition(a, b, c string) float64 {
+	p := 0.0
+	// the model of an empty trainer has no counts at all
+	if m.Total > 0 {
+		p = m.Lambdas[0] * float64(m.Unigrams[c]) / float64(m.Total)
+	}
+	if n := m.Unigrams[b]; n > 0 {
+		p += m.Lambdas[1] * float64(m.Bigrams[b+" "+c]) / float64(n)
+	}
+	if n := m.Bigrams[a+" "+b]; n > 0 {
+		p += m.Lambdas[2] * float64(m.Trigrams[a+" "+b+" "+c]) / float64(n)
+	}
+	return p + unseenTransition
+}
+
+// hmmClass returns the coarse class of the tag: its part of speech
+// (the first grammeme) plus the case, if any.
+func hmmClass(tag string) string {
+	end := strings.IndexAny(tag, ", ")
+	if end == -1 {
+		end = len(tag)
+	}
+	class := tag[:end]
+	if cases := LookupTag(tag).set.intersect(caseSet).Names(); len(cases) > 0 {
+		return class + "," + cases[0]
+	}
+	return class
+}
+
+// fallbackTag returns the tag for a token that is not a word,
+// using the OpenCorpora tags for such tokens.
+func fallbackTag(token string) string {
+	digits, letters, latin, others := 0, 0, 0, 0
+	for _, r := range token {
+		switch {
+		case unicode.IsDigit(r):
+			digits++
+		case r < unicode.MaxASCII && unicode.IsLetter(r):
+			latin++
+		case unicode.IsLetter(r):
+			letters++
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+		default:
+			others++
+		}
+	}
+	switch {
+	case others > 0 || letters > 0:
+		return "UNKN"
+	case digits > 0 && latin == 0:
+		return "NUMB"
+	case latin > 0:
+		return "LATN"
+	}
+	return "PNCT"
+}
+
+type hmmCandidate struct {
+	word, norm, tag, class string
+	emission               float64 // log(P(tag|word) / P(class))
+}
+
+func (m *HMM) candidates(token string) []hmmCandidate {
+	token = strings.ToLower(token)
+	words, norms, ids := xparse(token)
+	tags := tagNames(ids)
+	if len(words) == 0 {
+		tag := fallbackTag(token)
+		return []hmmCandidate{{token, token, tag, tag, -math.Log(m.classProb(tag))}}
+	}
+
+	probs := make([]float64, len(words))
+	sum := 0.0
+	for i, tag := range tags {
+		probs[i] = probability(token, tag)
+		sum += probs[i]
+	}
+
+	n := float64(len(words))
+	cands := make([]hmmCandidate, len(words))
+	for i := range words {
+		p := 1 / n
+		if sum > 0 {
+			p = probs[i] / sum
+		}
+		p = (1-emissionSmoothing)*p + emissionSmoothing/n
+		class := hmmClass(tags[i])
+		cands[i] = hmmCandidate{words[i], norms[i], tags[i], class, math.Log(p) - math.Log(m.classProb(class))}
+	}
+	return cands
+}
+
+// Disambiguate analyzes the tokens of a sentence with XParse and chooses
+// the most likely analysis of each token using the Viterbi algorithm.
+// It returns three slices of the same length as tokens; the tokens which
+// are not words get the OpenCorpora tags PNCT, NUMB, LATN or UNKN.
+func (m *HMM) Disambiguate(tokens []string) (words, norms, tags []string) {
+	words, norms, tags = m.disambiguate(tokens)
+	return words, norms, externalTags(tags)
+}
+
+func (m *HMM) disambiguate(tokens []string) (words, norms, tags []string) {
+	if len(tokens) == 0 {
+		return nil, nil, nil
+	}
+
+	cands := make([][]hmmCandidate, len(tokens))
+	for i, tok := range tokens {
+		cands[i] = m.candidates(tok)
+	}
+
+	// delta[i][p][c]: the best log-probability of the path ending with the
+	// candidates p at i-1 and c at i, with the best candidate at i-2 in back
+	type state struct {
+		score float64
+		back  int
+	}
+	class := func(i, j int) string {
+		if i < 0 {
+			return hmmStart
+		}
+		return cands[i][j].class
+	}
+	prevCount := func(i int) int {
+		if i < 0 {
+			return 1
+		}
+		return len(cands[i])
+	}
+
+	delta := make([][][]state, len(tokens))
+	for i := range tokens {
+		delta[i] = make([][]state, prevCount(i-1))
+		for p := range delta[i] {
+			delta[i][p] = make([]state, len(cands[i]))
+			for c, cand := range cands[i] {
+				best := state{math.Inf(-1), 0}
+				for pp := 0; pp < prevCount(i-2); pp++ {
+					score := 0.0
+					if i > 0 {
+						score = delta[i-1][pp][p].score
+					}
+					score += math.Log(m.transition(class(i-2, pp), class(i-1, p), cand.class))
+					if score > best.score {
+						best = state{score, pp}
+					}
+				}
+				best.score += cand.emission
+				delta[i][p][c] = best
+			}
+		}
+	}
+
+	// the transition to the end of the sentence
+	n := len(tokens)
+	bestP, bestC, bestScore := 0, 0, math.Inf(-1)
+	for p := range delta[n-1] {
+		for c := range delta[n-1][p] {
+			score := delta[n-1][p][c].score + math.Log(m.transition(class(n-2, p), class(n-1, c), hmmEnd))
+			if score > bestScore {
+				bestP, bestC, bestScore = p, c, score
+			}
+		}
+	}
+
+	path := make([]int, n)
+	path[n-1] = bestC
+	if n > 1 {
+		path[n-2] = bestP
+	}
+	for i := n - 1; i >= 2; i-- {
+		path[i-2] = delta[i][path[i-1]][path[i]].back
+	}
+
+	words = make([]string, n)
+	norms = make([]string, n)
+	tags = make([]string, n)
+	for i, j := range path {
+		c := cands[i][j]
+		words[i], norms[i], tags[i] = c.word, c.norm, c.tag
+	}
+	return words, norms, tags
+}
@@ -0,0 +1,133 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var hmmTrainingSentences = [][]string{
+	// они стали врачами
+	{"NPRO,plur,3per nomn", "VERB,perf,intr plur,past,indc", "NOUN,anim,masc plur,ablt"},
+	// мы стали друзьями
+	{"NPRO,1per plur,nomn", "VERB,perf,intr plur,past,indc", "NOUN,anim,masc plur,ablt"},
+	// нож из стали
+	{"NOUN,inan,masc sing,nomn", "PREP", "NOUN,inan,femn sing,gent"},
+	// дом из кирпича
+	{"NOUN,inan,masc sing,nomn", "PREP", "NOUN,inan,masc sing,gent"},
+	// все пришли .
+	{"ADJF,Subx,Apro plur,nomn", "VERB,perf,intr plur,past,indc", "PNCT"},
+}
+
+func trainTestHMM() *HMM {
+	tr := NewHMMTrainer()
+	for _, s := range hmmTrainingSentences {
+		tr.Add(s)
+	}
+	return tr.Model()
+}
+
+func TestHMMDisambiguate(t *testing.T) {
+	m := trainTestHMM()
+	for _, tc := range []struct {
+		sentence string
+		want     []string
+	}{
+		{"они стали врачами", []string{"NPRO", "VERB", "NOUN"}},
+		{"нож из стали", []string{"NOUN", "PREP", "NOUN"}},
+		{"все пришли .", []string{"ADJF", "VERB", "PNCT"}},
+	} {
+		_, _, tags := m.Disambiguate(strings.Fields(tc.sentence))
+		var pos []string
+		for _, tag := range tags {
+			pos = append(pos, strings.FieldsFunc(tag, func(r rune) bool { return r == ',' || r == ' ' })[0])
+		}
+		if !reflect.DeepEqual(pos, tc.want) {
+			t.Errorf("Disambiguate(%q): want %v, got %v", tc.sentence, tc.want, tags)
+		}
+	}
+}
+
+func TestHMMSaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "morph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	m := trainTestHMM()
+	fn := filepath.Join(dir, "hmm.json")
+	if err := m.Save(fn); err != nil {
+		t.Fatal(err)
+	}
+	m2, err := LoadHMM(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m, m2) {
+		t.Errorf("LoadHMM: the loaded model differs from the saved one")
+	}
+
+	sum := m.Lambdas[0] + m.Lambdas[1] + m.Lambdas[2]
+	if sum < 0.999 || sum > 1.001 {
+		t.Errorf("want the lambdas to sum to 1, got %v", m.Lambdas)
+	}
+}
+
+func TestFallbackTag(t *testing.T) {
+	for _, tc := range []struct{ token, want string }{
+		{".", "PNCT"},
+		{"«", "PNCT"},
+		{"2019", "NUMB"},
+		{"3,14", "NUMB"},
+		{"github", "LATN"},
+	} {
+		if got := fallbackTag(tc.token); got != tc.want {
+			t.Errorf("fallbackTag(%q): want %s, got %s", tc.token, tc.want, got)
+		}
+	}
+}
+
+func TestHMMEmptyModel(t *testing.T) {
+	m := NewHMMTrainer().Model()
+	if p := m.transition(hmmStart, hmmStart, "NOUN"); math.IsNaN(p) || p <= 0 {
+		t.Errorf("transition of the empty model: want a small positive probability, got %v", p)
+	}
+}
+
+func TestHMMAddTokens(t *testing.T) {
+	needDict(t)
+	tr := NewHMMTrainer()
+	if !tr.AddTokens([]CorpusToken{
+		{Form: "Мама", Tag: "NOUN,anim,femn,sing,nomn"},
+		{Form: "стали", UPOS: "NOUN", Feats: "Animacy=Inan|Case=Gen|Gender=Fem|Number=Sing"},
+		{Form: "."},
+	}) {
+		t.Fatal("AddTokens: the sentence is skipped")
+	}
+	for _, class := range []string{"NOUN,nomn", "NOUN,gent", "PNCT"} {
+		if tr.uni[class] != 1 {
+			t.Errorf("AddTokens: want the class %s counted once, got %d", class, tr.uni[class])
+		}
+	}
+	if tr.AddTokens([]CorpusToken{{Form: "стали"}}) {
+		t.Error("AddTokens: want the sentence with an unknown tag skipped")
+	}
+}
@@ -0,0 +1,198 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+)
+
+// WordFilter selects the dictionary words enumerated by a WordIterator.
+// The zero value selects all the words.
+type WordFilter struct {
+	Grammemes   []string // the grammemes the tag must have (in either tag format)
+	Exclude     []string // the grammemes the tag must not have
+	LemmaPrefix string   // the prefix of the normal form
+	LemmaSuffix string   // the suffix of the normal form
+	LemmasOnly  bool     // enumerate only the normal forms, not all the forms
+}
+
+// DictEntry is a word form of the dictionary.
+type DictEntry struct {
+	Word     string // the word form
+	Norm     string // its normal form
+	Tag      string // its tag
+	Paradigm int    // the paradigm number
+	Form     int    // the index of the form in the paradigm; 0 for the normal form
+}
+
+// WordIterator enumerates the words of the dictionary matching a filter,
+// e.g. the feminine nouns ending in -ь (the 3rd declension):
+//
+//	it, err := morph.NewWordIterator(morph.WordFilter{
+//		Grammemes:   []string{"NOUN", "femn"},
+//		LemmaSuffix: "ь",
+//		LemmasOnly:  true,
+//	})
+//	if err != nil {
+//		...
+//	}
+//	for it.Next() {
+//		fmt.Println(it.Entry().Word)
+//	}
+//
+// The words come in the dictionary order. As in Parse, the letter е
+// in the lemma prefix and suffix matches ё in the dictionary.
+// Walking the whole dictionary takes a few seconds, but a lemma prefix
+// with LemmasOnly restricts the walk to the words with the prefix.
+type WordIterator struct {
+	m        wordMatcher
+	c        completer
+	starts   []similarKey // the nodes to walk, with their keys
+	entry    DictEntry
+	valueBuf [payloadBufLen]byte
+}
+
+// wordMatcher matches the dictionary entries against a filter.
+type wordMatcher struct {
+	filter      WordFilter
+	want, avoid GrammemeSet
+}
+
+func newWordMatcher(f WordFilter) (wordMatcher, error) {
+	want, err := NewGrammemeSet(f.Grammemes...)
+	if err != nil {
+		return wordMatcher{}, err
+	}
+	avoid, err := NewGrammemeSet(f.Exclude...)
+	if err != nil {
+		return wordMatcher{}, err
+	}
+	return wordMatcher{f, want, avoid}, nil
+}
+
+// match returns the entry as a DictEntry and reports whether it matches the filter.
+func (m *wordMatcher) match(e entry) (DictEntry, bool) {
+	if m.filter.LemmasOnly && e.index != 0 {
+		return DictEntry{}, false
+	}
+	tag := paradigmTag(paradigms[e.para], e.index)
+	if set := tagSets[tag]; !set.HasAll(m.want) || set.Intersects(m.avoid) {
+		return DictEntry{}, false
+	}
+	norm := e.word
+	if e.index != 0 {
+		norm, _ = e.form(0)
+	}
+	if !hasPrefixYo(norm, m.filter.LemmaPrefix) || !hasSuffixYo(norm, m.filter.LemmaSuffix) {
+		return DictEntry{}, false
+	}
+	return DictEntry{e.word, norm, externalTag(tags[tag]), e.para, e.index}, true
+}
+
+// appendEntries appends to dst the entries of the word matching the filter.
+func (m *wordMatcher) appendEntries(dst []DictEntry, word string) []DictEntry {
+	for _, e := range entries(word) {
+		if e.word != word {
+			// a spelling with ё
+			continue
+		}
+		if de, ok := m.match(e); ok {
+			dst = append(dst, de)
+		}
+	}
+	return dst
+}
+
+// NewWordIterator returns an iterator over the dictionary words
+// matching the filter. It fails if a grammeme of the filter is unknown.
+func NewWordIterator(f WordFilter) (*WordIterator, error) {
+	if probDAWG == nil {
+		panic("not initialized; call Init or InitWith")
+	}
+	m, err := newWordMatcher(f)
+	if err != nil {
+		return nil, err
+	}
+	it := &WordIterator{m: m}
+	it.c.init(wordsDAWG.Dict, wordsDAWG.Guide)
+	if f.LemmasOnly {
+		// the normal forms are the words themselves
+		it.starts = wordsDAWG.similarPrefixes(nil, f.LemmaPrefix)
+	} else {
+		it.starts = []similarKey{{"", 0}}
+	}
+	it.nextStart()
+	return it, nil
+}
+
+// nextStart starts walking the next node, if any.
+func (it *WordIterator) nextStart() bool {
+	if len(it.starts) == 0 {
+		return false
+	}
+	it.c.start(it.starts[0].index, it.starts[0].key)
+	it.starts = it.starts[1:]
+	return true
+}
+
+// Next advances the iterator to the next word and reports
+// whether there is one.
+func (it *WordIterator) Next() bool {
+	for {
+		for it.c.next() {
+			if it.match() {
+				return true
+			}
+		}
+		if !it.nextStart() {
+			return false
+		}
+	}
+}
+
+// match decodes the current key of the completer into the entry
+// and reports whether it matches the filter.
+func (it *WordIterator) match() bool {
+	// the keys are the words followed by the separator and the payload
+	key := it.c.key
+	i := bytes.IndexByte(key, payloadSeparator)
+	if i < 0 {
+		return false
+	}
+	v := decodePayload(it.valueBuf[:], key[i+1:])
+	para := int(binary.BigEndian.Uint16(v))
+	index := int(binary.BigEndian.Uint16(v[2:]))
+	e, ok := it.m.match(entry{string(key[:i]), para, index})
+	it.entry = e
+	return ok
+}
+
+// Entry returns the current word.
+func (it *WordIterator) Entry() DictEntry {
+	return it.entry
+}
+
+// hasPrefixYo reports whether s starts with prefix, where е
+// in the prefix matches ё in s.
+func hasPrefixYo(s, prefix string) bool {
+	return strings.HasPrefix(s, prefix) || strings.HasPrefix(strings.Replace(s, "ё", "е", -1), prefix)
+}
+
+// hasSuffixYo reports whether s ends with suffix, where е
+// in the suffix matches ё in s.
+func hasSuffixYo(s, suffix string) bool {
+	return strings.HasSuffix(s, suffix) || strings.HasSuffix(strings.Replace(s, "ё", "е", -1), suffix)
+}
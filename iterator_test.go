@@ -0,0 +1,60 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import "testing"
+
+func TestWordIterator(t *testing.T) {
+	it, err := NewWordIterator(WordFilter{
+		Grammemes:   []string{"NOUN", "femn"},
+		LemmaPrefix: "стал",
+		LemmasOnly:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := NewGrammemeSet("NOUN", "femn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for it.Next() {
+		e := it.Entry()
+		if e.Form != 0 || e.Word != e.Norm || !LookupTag(e.Tag).HasAll(want) {
+			t.Errorf("unexpected entry %+v", e)
+		}
+		found = found || e.Word == "сталь"
+	}
+	if !found {
+		t.Error("сталь not found")
+	}
+
+	it, err = NewWordIterator(WordFilter{Grammemes: []string{"plur", "datv"}, Exclude: []string{"ADJF"}, LemmaPrefix: "ёж"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var words []string
+	for it.Next() {
+		if e := it.Entry(); e.Norm == "ёж" {
+			words = append(words, e.Word)
+		}
+	}
+	if len(words) != 1 || words[0] != "ежам" {
+		t.Errorf("want the plural dative of ёж, got %v", words)
+	}
+
+	if _, err := NewWordIterator(WordFilter{Grammemes: []string{"nosuchgrammeme"}}); err == nil {
+		t.Error("want an error for an unknown grammeme")
+	}
+}
@@ -0,0 +1,214 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// entry is an occurrence of a word in the dictionary.
+type entry struct {
+	word  string // the word with the letter ё fixed
+	para  int    // the paradigm number
+	index int    // the index of the form in the paradigm
+}
+
+// entries returns the occurrences of the (lowercase) word in the dictionary.
+func entries(word string) []entry {
+	var c completer
+	c.init(wordsDAWG.Dict, wordsDAWG.Guide)
+	var keyBuf [4]similarKey
+	var valueBuf [payloadBufLen]byte
+
+	var es []entry
+	for _, sk := range wordsDAWG.similarKeys(keyBuf[:0], word) {
+		c.start(sk.index, "")
+		for c.next() {
+			v := decodePayload(valueBuf[:], c.key)
+			es = append(es, entry{
+				word:  sk.key,
+				para:  int(binary.BigEndian.Uint16(v)),
+				index: int(binary.BigEndian.Uint16(v[2:])),
+			})
+		}
+	}
+	return es
+}
+
+func (e entry) tag() string {
+	para := paradigms[e.para]
+	return tags[paradigmTag(para, e.index)]
+}
+
+// form returns the i-th form of the paradigm of the entry and its tag.
+func (e entry) form(i int) (string, string) {
+	para := paradigms[e.para]
+	prefix, suffix, _ := prefixSuffixTag(para, e.index)
+	stem := strings.TrimSuffix(strings.TrimPrefix(e.word, prefix), suffix)
+	prefix, suffix, tag := prefixSuffixTag(para, i)
+	return prefix + stem + suffix, tag
+}
+
+// findEntry returns the occurrence of the word with the given tag
+// or, if the tag is empty, with the most probable one.
+func findEntry(word, tag string) (entry, bool) {
+	tag = InternalTag(tag)
+	if tag == "" {
+		_, _, ids := parse(word)
+		if len(ids) == 0 {
+			return entry{}, false
+		}
+		tag = tags[ids[0]]
+	}
+	es := entries(word)
+	for _, e := range es {
+		if e.tag() == tag {
+			return e, true
+		}
+	}
+	t := LookupTag(tag)
+	for _, e := range es {
+		if LookupTag(e.tag()).Equal(t) {
+			return e, true
+		}
+	}
+	return entry{}, false
+}
+
+// Lexeme returns all the forms of the lexeme of the (lowercase) word
+// which has the given tag (as returned by Parse, the order of the grammemes
+// does not matter) or, if the tag is empty, the most probable one.
+// It returns three slices of the same length, as Parse does; the first
+// form is the normal form. Only the words found in the dictionary are
+// supported; Lexeme returns nil slices for the other words.
+func Lexeme(word, tag string) (words, norms, tags []string) {
+	if probDAWG == nil {
+		panic("not initialized; call Init or InitWith")
+	}
+	e, ok := findEntry(word, tag)
+	if !ok {
+		return nil, nil, nil
+	}
+	return e.forms()
+}
+
+// forms returns all the forms of the lexeme of the entry, as Lexeme does.
+func (e entry) forms() (words, norms, tags []string) {
+	n := len(paradigms[e.para]) / 3
+	norm, _ := e.form(0)
+	for i := 0; i < n; i++ {
+		w, t := e.form(i)
+		words = append(words, w)
+		norms = append(norms, norm)
+		tags = append(tags, externalTag(t))
+	}
+	return words, norms, tags
+}
+
+// LexemeID returns the identifier of the lexeme of the analysis
+// (words[i], norms[i], tags[i]) returned by Parse, e.g. "1234:сталь".
+// The identifier consists of the paradigm number and the normal form, so
+// it tells the homonyms apart (e.g. the noun сталь and the verb стать
+// sharing the form стали) and stays the same for the same version of
+// the dictionary (see Info), but not across the versions.
+// The ok result is false if the analysis is not from the dictionary.
+func LexemeID(word, norm, tag string) (id string, ok bool) {
+	if probDAWG == nil {
+		panic("not initialized; call Init or InitWith")
+	}
+	tag = InternalTag(tag)
+	for _, e := range entries(word) {
+		if e.word != word || e.tag() != tag {
+			continue
+		}
+		if n, _ := e.form(0); n == norm {
+			return lexemeID(e.para, norm), true
+		}
+	}
+	return "", false
+}
+
+// LexemeID returns the identifier of the lexeme of the entry (see the LexemeID function).
+func (e DictEntry) LexemeID() string {
+	return lexemeID(e.Paradigm, e.Norm)
+}
+
+func lexemeID(para int, norm string) string {
+	return strconv.Itoa(para) + ":" + norm
+}
+
+// LexemeByID returns all the forms of the lexeme with the identifier
+// returned by LexemeID, as Lexeme does. It fails if the identifier is
+// malformed or there is no such lexeme in the dictionary.
+func LexemeByID(id string) (words, norms, tags []string, err error) {
+	if probDAWG == nil {
+		panic("not initialized; call Init or InitWith")
+	}
+	i := strings.IndexByte(id, ':')
+	if i < 0 {
+		return nil, nil, nil, fmt.Errorf("invalid lexeme id: %q", id)
+	}
+	para, err := strconv.Atoi(id[:i])
+	if err != nil || para < 0 || para >= len(paradigms) {
+		return nil, nil, nil, fmt.Errorf("invalid lexeme id: %q", id)
+	}
+	norm := id[i+1:]
+	for _, e := range entries(norm) {
+		if e.word != norm || e.para != para || e.index != 0 {
+			continue
+		}
+		words, norms, tags = e.forms()
+		return words, norms, tags, nil
+	}
+	return nil, nil, nil, fmt.Errorf("unknown lexeme: %q", id)
+}
+
+// Inflect returns the form of the lexeme of the (lowercase) word which
+// has all the given grammemes, and its tag. The word is taken with the given
+// tag or, if the tag is empty, with the most probable one. If several forms
+// have the grammemes, the one sharing the most grammemes with the word
+// is chosen, e.g. Inflect("ёж", "", "plur", "datv") returns "ежам".
+// The ok result is false if the word is not in the dictionary or there is
+// no form with the grammemes.
+func Inflect(word, tag string, grammemes ...string) (form, formTag string, ok bool) {
+	if probDAWG == nil {
+		panic("not initialized; call Init or InitWith")
+	}
+	want, err := NewGrammemeSet(grammemes...)
+	if err != nil {
+		return "", "", false
+	}
+	e, found := findEntry(word, tag)
+	if !found {
+		return "", "", false
+	}
+
+	src := LookupTag(e.tag()).Grammemes()
+	best := -1
+	n := len(paradigms[e.para]) / 3
+	for i := 0; i < n; i++ {
+		w, t := e.form(i)
+		s := LookupTag(t).Grammemes()
+		if !s.HasAll(want) {
+			continue
+		}
+		if score := s.intersect(src).count(); score > best {
+			form, formTag, best = w, t, score
+		}
+	}
+	return form, externalTag(formTag), best >= 0
+}
@@ -0,0 +1,98 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import "testing"
+
+func TestLexeme(t *testing.T) {
+	words, norms, tags := Lexeme("ежами", "")
+	if len(words) == 0 || len(words) != len(norms) || len(words) != len(tags) {
+		t.Fatalf("Lexeme(%q): got %v %v %v", "ежами", words, norms, tags)
+	}
+	if words[0] != "ёж" || norms[0] != "ёж" {
+		t.Errorf("Lexeme(%q): want the normal form %q first, got %q", "ежами", "ёж", words[0])
+	}
+	found := false
+	for _, w := range words {
+		found = found || w == "ежам"
+	}
+	if !found {
+		t.Errorf("Lexeme(%q): no %q in %v", "ежами", "ежам", words)
+	}
+
+	if words, _, _ := Lexeme("ежами", "VERB,perf,intr plur,past,indc"); words != nil {
+		t.Errorf("Lexeme with a wrong tag: want nil, got %v", words)
+	}
+}
+
+func TestInflect(t *testing.T) {
+	tests := []struct {
+		word      string
+		grammemes []string
+		want      string
+	}{
+		{"ёж", []string{"plur", "datv"}, "ежам"},
+		{"мама", []string{"accs"}, "маму"},
+		{"красный", []string{"femn"}, "красная"},
+	}
+	for _, tt := range tests {
+		form, tag, ok := Inflect(tt.word, "", tt.grammemes...)
+		if !ok || form != tt.want {
+			t.Errorf("Inflect(%q, %v): want %q, got %q (%q, %v)", tt.word, tt.grammemes, tt.want, form, tag, ok)
+		}
+	}
+	if _, _, ok := Inflect("ёж", "", "bogus"); ok {
+		t.Error("Inflect with an unknown grammeme: want !ok")
+	}
+}
+
+func TestLexemeID(t *testing.T) {
+	words, norms, tags := Parse("стали")
+	ids := make(map[string]string) // normal form -> id
+	for i := range words {
+		id, ok := LexemeID(words[i], norms[i], tags[i])
+		if !ok {
+			t.Fatalf("LexemeID(%q, %q, %q): not found", words[i], norms[i], tags[i])
+		}
+		if prev, seen := ids[norms[i]]; seen && prev != id {
+			t.Errorf("LexemeID: different ids %q and %q for %s", prev, id, norms[i])
+		}
+		ids[norms[i]] = id
+	}
+	if len(ids) != 2 || ids["сталь"] == "" || ids["стать"] == "" || ids["сталь"] == ids["стать"] {
+		t.Fatalf("LexemeID: want distinct ids for сталь and стать, got %v", ids)
+	}
+
+	for norm, id := range ids {
+		words, norms, tags, err := LexemeByID(id)
+		if err != nil {
+			t.Fatalf("LexemeByID(%q): %v", id, err)
+		}
+		if len(words) == 0 || words[0] != norm || norms[0] != norm {
+			t.Errorf("LexemeByID(%q): want the normal form %q first, got %v", id, norm, words)
+		}
+		if id2, ok := LexemeID(words[0], norms[0], tags[0]); !ok || id2 != id {
+			t.Errorf("LexemeID of the normal form of %q: got %q, %v", id, id2, ok)
+		}
+	}
+
+	if _, ok := LexemeID("стали", "стать", "NOUN,inan,femn sing,gent"); ok {
+		t.Error("LexemeID with a wrong normal form: want !ok")
+	}
+	for _, id := range []string{"", "сталь", "x:сталь", "-1:сталь", "999999:сталь", "0:нетслова"} {
+		if _, _, _, err := LexemeByID(id); err == nil {
+			t.Errorf("LexemeByID(%q): want an error", id)
+		}
+	}
+}
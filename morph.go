@@ -13,6 +13,9 @@
 
 // Package morph provides a simple morphological analyzer for Russian language,
 // using the compiled dictionaries from pymorphy2.
+//
+// The dictionary data is read-only after Init or InitWith returns,
+// so the analysis functions are safe for concurrent use.
 package morph
 
 import (
@@ -24,7 +27,6 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"sort"
 	"strings"
 )
 
@@ -38,47 +40,63 @@ var (
 	wordsDAWG       *dawg
 	probDAWG        *dawg
 	predictionDAWGs []*dawg
+	dictInfo        DictionaryInfo
 )
 
-type parse struct {
-	words []string
-	norms []string
-	tags  []string
-	probs []float64
-}
-
-func (p *parse) Len() int           { return len(p.words) }
-func (p *parse) Less(i, j int) bool { return p.probs[i] > p.probs[j] }
-func (p *parse) Swap(i, j int) {
-	p.words[i], p.words[j] = p.words[j], p.words[i]
-	p.norms[i], p.norms[j] = p.norms[j], p.norms[i]
-	p.tags[i], p.tags[j] = p.tags[j], p.tags[i]
-	p.probs[i], p.probs[j] = p.probs[j], p.probs[i]
-}
-
 // Parse analyzes the (lowercase) word and returns three slices of the same length.
 // Each triple (words[i], norms[i], tags[i]) represents an analysis, where:
 // - words[i] is the word with the letter ё fixed;
 // - norms[i] is the normal form of the word;
 // - tags[i] is the grammatical tag, consisting of the word's grammemes.
 // The analyzes are sorted by probability (the first one is the most probable).
+// The tags are in the format chosen with InitWithOptions.
 func Parse(word string) (words, norms, tags []string) {
 	if probDAWG == nil {
 		panic("not initialized; call Init or InitWith")
 	}
+	words, norms, ids := parse(word)
+	return words, norms, externalTags(tagNames(ids))
+}
+
+// parse is Parse returning the indices of the tags in tags
+// instead of the tags.
+func parse(word string) (words, norms []string, ids []int) {
+	c := dictCache
+	if c != nil {
+		if words, norms, ids, ok := c.get(word); ok {
+			return words, norms, ids
+		}
+	}
 
-	var probs []float64
+	words, norms, ids = lookup(word)
+	if c != nil && len(words) > 0 {
+		c.add(word, words, norms, ids)
+	}
+	return words, norms, ids
+}
+
+func lookup(word string) (words, norms []string, ids []int) {
+	var probBuf [16]float64
+	probs := probBuf[:0]
 	hasNonzeroProb := false
 
-	for _, it := range wordsDAWG.similarItems(word) {
-		for _, v := range it.values {
+	var c completer
+	c.init(wordsDAWG.Dict, wordsDAWG.Guide)
+	var keyBuf [4]similarKey
+	var valueBuf [payloadBufLen]byte
+
+	for _, sk := range wordsDAWG.similarKeys(keyBuf[:0], word) {
+		c.start(sk.index, "")
+		for c.next() {
+			v := decodePayload(valueBuf[:], c.key)
 			paraNum := int(binary.BigEndian.Uint16(v))
 			para := paradigms[paraNum]
 			index := int(binary.BigEndian.Uint16(v[2:]))
 
-			prefix, suffix, tag := prefixSuffixTag(para, index)
+			prefix, suffix, _ := prefixSuffixTag(para, index)
+			id := paradigmTag(para, index)
 
-			norm := it.key
+			norm := sk.key
 			if index != 0 {
 				stem := strings.TrimPrefix(norm, prefix)
 				stem = strings.TrimSuffix(stem, suffix)
@@ -86,11 +104,11 @@ func Parse(word string) (words, norms, tags []string) {
 				norm = pr + stem + su
 			}
 
-			words = append(words, it.key)
+			words = append(words, sk.key)
 			norms = append(norms, norm)
-			tags = append(tags, tag)
+			ids = append(ids, id)
 
-			prob := float64(probDAWG.Dict.find(word+":"+tag)) / 1e6
+			prob := probability(word, tags[id])
 			if prob > 0 {
 				hasNonzeroProb = true
 			}
@@ -99,10 +117,52 @@ func Parse(word string) (words, norms, tags []string) {
 	}
 
 	if hasNonzeroProb {
-		sort.Stable(&parse{words, norms, tags, probs})
+		sortByProb(words, norms, ids, probs)
+	}
+
+	return words, norms, ids
+}
+
+// probability returns P(tag|word) looking up the key word+":"+tag
+// without building it.
+func probability(word, tag string) float64 {
+	if probOverride != nil {
+		if index := probOverride.Dict.follow(word, 0); index != 0 {
+			if index = probOverride.Dict.followByte(':', index); index != 0 {
+				return tagProbability(probOverride.Dict, tag, index)
+			}
+		}
 	}
 
-	return words, norms, tags
+	d := probDAWG.Dict
+	index := d.follow(word, 0)
+	if index == 0 {
+		return 0
+	}
+	if index = d.followByte(':', index); index == 0 {
+		return 0
+	}
+	return tagProbability(d, tag, index)
+}
+
+func tagProbability(d dictionary, tag string, index uint32) float64 {
+	if index = d.follow(tag, index); index == 0 || !d.hasValue(index) {
+		return 0
+	}
+	return float64(d.value(index)) / 1e6
+}
+
+// sortByProb stably sorts the analyses by descending probability.
+// There are only a few analyses per word, so the insertion sort will do.
+func sortByProb(words, norms []string, ids []int, probs []float64) {
+	for i := 1; i < len(probs); i++ {
+		for j := i; j > 0 && probs[j] > probs[j-1]; j-- {
+			words[j], words[j-1] = words[j-1], words[j]
+			norms[j], norms[j-1] = norms[j-1], norms[j]
+			ids[j], ids[j-1] = ids[j-1], ids[j]
+			probs[j], probs[j-1] = probs[j-1], probs[j]
+		}
+	}
 }
 
 // Init tries to find the path to the installed pymorphy2 dictionaries by invoking python and calls InitWith with the found directory.
@@ -120,13 +180,26 @@ func Init() error {
 
 // InitWith loads the pymorphy2 dictionary data from the given directory.
 func InitWith(dir string) error {
+	return InitWithOptions(dir, Options{})
+}
+
+// InitWithOptions loads the pymorphy2 dictionary data from the given
+// directory or, if it is empty, from the directory found as Init does.
+func InitWithOptions(dir string, opts Options) error {
 	if probDAWG != nil {
 		return ErrAlreadyInitialized
 	}
+	if dir == "" {
+		var err error
+		if dir, err = dataPath(); err != nil {
+			return err
+		}
+	}
 
 	prefixesPath := filepath.Join(dir, "paradigm-prefixes.json")
 	suffixesPath := filepath.Join(dir, "suffixes.json")
 	tagsPath := filepath.Join(dir, "gramtab-opencorpora-int.json")
+	grammemesPath := filepath.Join(dir, "grammemes.json")
 	paradigmsPath := filepath.Join(dir, "paradigms.array")
 	dawgPath := filepath.Join(dir, "words.dawg")
 	probPath := filepath.Join(dir, "p_t_given_w.intdawg")
@@ -146,6 +219,18 @@ func InitWith(dir string) error {
 		prefixes = []string{"", "по", "наи"}
 	}
 
+	if err := loadGrammemes(grammemesPath); err != nil {
+		return err
+	}
+
+	if err := indexTags(); err != nil {
+		return err
+	}
+
+	if err := loadExtTags(filepath.Join(dir, "gramtab-opencorpora-ext.json"), opts.TagFormat); err != nil {
+		return err
+	}
+
 	suffixes, err = loadStringArray(suffixesPath)
 	if err != nil {
 		return err
@@ -175,9 +260,56 @@ func InitWith(dir string) error {
 		predictionDAWGs = append(predictionDAWGs, d)
 	}
 
+	dictInfo = DictionaryInfo{
+		Dir:       dir,
+		Tags:      len(tags),
+		Paradigms: len(paradigms),
+	}
+	dictInfo.Meta, err = loadMeta(filepath.Join(dir, "meta.json"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
 	return nil
 }
 
+// DictionaryInfo describes the loaded dictionary.
+type DictionaryInfo struct {
+	Dir       string                 `json:"dir"`
+	Meta      map[string]interface{} `json:"meta,omitempty"` // the contents of meta.json, e.g. "source_revision"; nil if it is missing
+	Tags      int                    `json:"tags"`
+	Paradigms int                    `json:"paradigms"`
+}
+
+// Info returns the description of the loaded dictionary.
+func Info() DictionaryInfo {
+	if probDAWG == nil {
+		panic("not initialized; call Init or InitWith")
+	}
+	return dictInfo
+}
+
+// loadMeta loads meta.json, which holds a list of key-value pairs.
+func loadMeta(fn string) (map[string]interface{}, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var pairs [][2]interface{}
+	if err := json.NewDecoder(f).Decode(&pairs); err != nil {
+		return nil, err
+	}
+	meta := make(map[string]interface{}, len(pairs))
+	for _, p := range pairs {
+		if k, ok := p[0].(string); ok {
+			meta[k] = p[1]
+		}
+	}
+	return meta, nil
+}
+
 func dataPath() (string, error) {
 	cmd := exec.Command("python", "-c", "import pymorphy2_dicts_ru as p; print(p.__path__[0])")
 	var buf bytes.Buffer
@@ -233,6 +365,11 @@ func loadParadigms(fn string) error {
 	return nil
 }
 
+// paradigmTag returns the index of the tag of the i-th form of the paradigm.
+func paradigmTag(para []uint16, i int) int {
+	return int(para[i+len(para)/3])
+}
+
 func prefixSuffixTag(para []uint16, i int) (string, string, string) {
 	n := len(para) / 3
 	suffixIndex := para[i]
@@ -13,7 +13,10 @@
 
 package morph
 
-import "testing"
+import (
+	"encoding/base64"
+	"testing"
+)
 
 var benchWords = []string{
 	"абдулхаковичам",
@@ -276,8 +279,56 @@ var benchWords = []string{
 
 var tmp []string
 
+// checkPayloadAllocs fails if decoding a payload of the words dawg allocates.
+func checkPayloadAllocs(tb testing.TB) {
+	key := []byte(base64.StdEncoding.EncodeToString([]byte{0, 1, 0, 2}))
+	var buf [payloadBufLen]byte
+	if n := testing.AllocsPerRun(100, func() { decodePayload(buf[:], key) }); n != 0 {
+		tb.Fatalf("decodePayload: %v allocations per payload, want 0", n)
+	}
+}
+
+func TestDecodePayloadAllocs(t *testing.T) {
+	checkPayloadAllocs(t)
+}
+
 func BenchmarkParse(b *testing.B) {
+	checkPayloadAllocs(b)
+	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
 		tmp, _, _ = Parse(benchWords[i%len(benchWords)])
 	}
 }
+
+func BenchmarkParseParallel(b *testing.B) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			Parse(benchWords[i%len(benchWords)])
+			i++
+		}
+	})
+}
+
+func BenchmarkXParse(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tmp, _, _ = XParse(extendedTestCases[i%len(extendedTestCases)].word)
+	}
+}
+
+func BenchmarkSimilarKeys(b *testing.B) {
+	b.ReportAllocs()
+	var buf [4]similarKey
+	for i := 0; i < b.N; i++ {
+		wordsDAWG.similarKeys(buf[:0], benchWords[i%len(benchWords)])
+	}
+}
+
+func BenchmarkProbability(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		probability("стали", "VERB,perf,intr plur,past,indc")
+	}
+}
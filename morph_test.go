@@ -20,6 +20,14 @@ import (
 
 func init() { Init() }
 
+// needDict skips the test if the dictionary could not be loaded,
+// for the tests of the functions panicking without it.
+func needDict(t testing.TB) {
+	if probDAWG == nil {
+		t.Skip("the dictionary is not initialized")
+	}
+}
+
 var testCases = []struct {
 	word string
 	want [3][]string
@@ -0,0 +1,317 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import "strings"
+
+// msdValue is a value of a MULTEXT-East attribute and
+// the OpenCorpora grammeme it corresponds to.
+type msdValue struct {
+	grammeme string
+	letter   byte
+}
+
+// the values of the MULTEXT-East attributes; the first grammeme
+// with a letter is the one FromMSD chooses
+var (
+	msdGender = []msdValue{{"masc", 'm'}, {"femn", 'f'}, {"neut", 'n'}, {"GNdr", 'c'}, {"Ms-f", 'c'}}
+	msdNumber = []msdValue{{"sing", 's'}, {"plur", 'p'}}
+	msdCase   = []msdValue{
+		{"nomn", 'n'}, {"gent", 'g'}, {"gen1", 'g'}, {"gen2", 'g'}, {"datv", 'd'}, {"accs", 'a'}, {"acc2", 'a'},
+		{"ablt", 'i'}, {"loct", 'l'}, {"loc1", 'l'}, {"loc2", 'l'}, {"voct", 'v'},
+	}
+	msdAnimate = []msdValue{{"anim", 'y'}, {"inan", 'n'}}
+	msdTense   = []msdValue{{"pres", 'p'}, {"futr", 'f'}, {"past", 's'}}
+	msdPerson  = []msdValue{{"1per", '1'}, {"2per", '2'}, {"3per", '3'}}
+	msdVoice   = []msdValue{{"actv", 'a'}, {"pssv", 'p'}}
+	msdAspect  = []msdValue{{"perf", 'p'}, {"impf", 'e'}}
+)
+
+// the attributes after the category letter of the MSDs; nil stands for
+// the attributes handled by MSD and FromMSD themselves: the types, the verb
+// form, the degree, the definiteness (full or short form), the syntactic
+// type of the pronouns and the form of the numerals
+var msdLayouts = map[byte][][]msdValue{
+	'N': {nil, msdGender, msdNumber, msdCase, msdAnimate},
+	'V': {nil, nil, msdTense, msdPerson, msdNumber, msdGender, msdVoice, nil, msdAspect, msdCase},
+	'A': {nil, nil, msdGender, msdNumber, msdCase, nil},
+	'P': {nil, msdPerson, msdGender, msdNumber, msdCase, msdAnimate, nil},
+	'M': {nil, msdGender, msdNumber, msdCase, nil},
+	'R': {nil},
+	'S': {nil},
+	'C': {nil},
+	'Q': {},
+	'I': {},
+}
+
+// the MULTEXT-East categories for the OpenCorpora parts of speech
+var msdCategories = map[string]byte{
+	"NOUN": 'N',
+	"ADJF": 'A',
+	"ADJS": 'A',
+	"COMP": 'A',
+	"VERB": 'V',
+	"INFN": 'V',
+	"PRTF": 'V',
+	"PRTS": 'V',
+	"GRND": 'V',
+	"NUMR": 'M',
+	"ADVB": 'R',
+	"NPRO": 'P',
+	"PRED": 'R',
+	"PREP": 'S',
+	"CONJ": 'C',
+	"PRCL": 'Q',
+	"INTJ": 'I',
+}
+
+// MSD converts the tag to a MULTEXT-East positional morphosyntactic
+// description, e.g. "Ncmsny" for "NOUN,anim,masc sing,nomn", with the
+// unspecified trailing attributes omitted. The attributes are:
+//
+//	N  Type (c, p), Gender, Number, Case, Animate
+//	V  Type (m), VForm (i, m, n, p, g), Tense, Person, Number, Gender,
+//	   Voice, Definiteness (f, s), Aspect (p, e), Case
+//	A  Type (f, s), Degree (p, c, s), Gender, Number, Case, Definiteness
+//	P  Type (d, q, s), Person, Gender, Number, Case, Animate,
+//	   Syntactic type (n, a)
+//	M  Type (c, o), Gender, Number, Case, Form (l)
+//	R  Degree;  S  Type (p);  C  Type;  Q;  I
+//
+// The conversion loses the distinctions MULTEXT-East does not make: the second
+// genitive, accusative and locative become the plain cases, the predicatives
+// become adverbs (R), the proper nouns are not told apart by kind, and the
+// common gender (GNdr, Ms-f) becomes c. The pronoun types other than
+// demonstrative, interrogative and possessive and the conjunction types
+// are left unspecified, as the tags do not tell them. The tags of the
+// punctuation, numbers and non-Cyrillic words give "".
+func (t Tag) MSD() string {
+	names := splitTag(t.str)
+	if len(names) == 0 {
+		return ""
+	}
+	pos := names[0]
+	cat, ok := msdCategories[pos]
+	if !ok {
+		return ""
+	}
+	has := func(name string) bool {
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	}
+	if pos == "ADJF" && has("Apro") {
+		cat = 'P'
+	} else if pos == "ADJF" && has("Anum") {
+		cat = 'M'
+	}
+
+	layout := msdLayouts[cat]
+	msd := make([]byte, 1+len(layout))
+	msd[0] = cat
+	for i, values := range layout {
+		msd[i+1] = '-'
+		for _, v := range values {
+			if has(v.grammeme) {
+				msd[i+1] = v.letter
+				break
+			}
+		}
+	}
+
+	switch cat {
+	case 'N':
+		msd[1] = 'c'
+		for _, name := range []string{"Name", "Surn", "Patr", "Geox", "Orgn", "Trad"} {
+			if has(name) {
+				msd[1] = 'p'
+			}
+		}
+	case 'V':
+		msd[1] = 'm'
+		switch {
+		case pos == "INFN":
+			msd[2] = 'n'
+		case pos == "PRTF" || pos == "PRTS":
+			msd[2] = 'p'
+			msd[8] = 'f'
+			if pos == "PRTS" {
+				msd[8] = 's'
+			}
+		case pos == "GRND":
+			msd[2] = 'g'
+		case has("impr"):
+			msd[2] = 'm'
+		default:
+			msd[2] = 'i'
+		}
+	case 'A':
+		msd[1] = 'f'
+		if has("Poss") {
+			msd[1] = 's'
+		}
+		switch {
+		case pos == "COMP":
+			msd[2] = 'c'
+		case has("Supr"):
+			msd[2] = 's'
+		default:
+			msd[2] = 'p'
+		}
+		switch pos {
+		case "ADJF":
+			msd[6] = 'f'
+		case "ADJS":
+			msd[6] = 's'
+		}
+	case 'P':
+		switch {
+		case has("Dmns"):
+			msd[1] = 'd'
+		case has("Ques"):
+			msd[1] = 'q'
+		case has("Poss"):
+			msd[1] = 's'
+		}
+		msd[7] = 'n'
+		if pos == "ADJF" {
+			msd[7] = 'a'
+		}
+	case 'M':
+		msd[1] = 'c'
+		if pos == "ADJF" {
+			msd[1] = 'o'
+		}
+		msd[5] = 'l'
+	case 'S':
+		msd[1] = 'p'
+	}
+	return strings.TrimRight(string(msd), "-")
+}
+
+// FromMSD returns the tags of the loaded dictionary matching the MULTEXT-East
+// MSD, e.g. "Ncmsny", the ones with the fewest grammemes first. The MSD may
+// omit the trailing attributes and have '-' for the unspecified ones.
+// As MULTEXT-East makes fewer distinctions (see Tag.MSD), an MSD may match
+// several tags. The conversion back chooses the plain cases, the adverbs
+// (ADVB) for R and the common gender of the nouns (GNdr) or Ms-f for c.
+// It returns nil for an unknown category, a verb MSD without the verb form
+// or if no tag matches.
+func FromMSD(msd string) []Tag {
+	if probDAWG == nil {
+		panic("not initialized; call Init or InitWith")
+	}
+	if msd == "" {
+		return nil
+	}
+	cat := msd[0]
+	layout, ok := msdLayouts[cat]
+	if !ok {
+		return nil
+	}
+	attr := func(i int) byte {
+		if i < len(msd) {
+			return msd[i]
+		}
+		return '-'
+	}
+
+	var names []string
+	for i, values := range layout {
+		for _, v := range values {
+			if v.letter == attr(i+1) {
+				name := v.grammeme
+				if name == "GNdr" && cat != 'N' {
+					name = "Ms-f"
+				}
+				names = append(names, name)
+				break
+			}
+		}
+	}
+
+	var pos string
+	switch cat {
+	case 'N':
+		pos = "NOUN"
+	case 'V':
+		switch attr(2) {
+		case 'i':
+			pos = "VERB"
+			names = append(names, "indc")
+		case 'm':
+			pos = "VERB"
+			names = append(names, "impr")
+		case 'n':
+			pos = "INFN"
+		case 'p':
+			pos = "PRTF"
+			if attr(8) == 's' {
+				pos = "PRTS"
+			}
+		case 'g':
+			pos = "GRND"
+		default:
+			return nil
+		}
+	case 'A':
+		pos = "ADJF"
+		switch {
+		case attr(2) == 'c':
+			pos = "COMP"
+		case attr(6) == 's':
+			pos = "ADJS"
+		}
+		if attr(2) == 's' {
+			names = append(names, "Supr")
+		}
+		if attr(1) == 's' {
+			names = append(names, "Poss")
+		}
+	case 'P':
+		pos = "NPRO"
+		if attr(7) == 'a' {
+			pos = "ADJF"
+			names = append(names, "Apro")
+		}
+		switch attr(1) {
+		case 'd':
+			names = append(names, "Dmns")
+		case 'q':
+			names = append(names, "Ques")
+		case 's':
+			names = append(names, "Poss")
+		}
+	case 'M':
+		pos = "NUMR"
+		if attr(1) == 'o' {
+			pos = "ADJF"
+			names = append(names, "Anum")
+		}
+	case 'R':
+		pos = "ADVB"
+	case 'S':
+		pos = "PREP"
+	case 'C':
+		pos = "CONJ"
+	case 'Q':
+		pos = "PRCL"
+	case 'I':
+		pos = "INTJ"
+	}
+	return matchingTags(pos, names)
+}
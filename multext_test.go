@@ -0,0 +1,88 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import "testing"
+
+func TestTagMSD(t *testing.T) {
+	tests := []struct {
+		tag, msd string
+	}{
+		{"NOUN,inan,masc sing,nomn", "Ncmsnn"},
+		{"NOUN,anim,femn,Name sing,datv", "Npfsdy"},
+		{"NOUN,inan,masc sing,loc2", "Ncmsln"},
+		{"ADJF,Qual femn,sing,nomn", "Afpfsnf"},
+		{"ADJS,Qual neut,sing", "Afpns-s"},
+		{"ADJS,Qual Ms-f,sing", "Afpcs-s"},
+		{"COMP,Qual", "Afc"},
+		{"VERB,impf,tran sing,3per,pres,indc", "Vmip3s---e"},
+		{"VERB,perf,intr femn,sing,past,indc", "Vmis-sf--p"},
+		{"INFN,perf,intr", "Vmn------p"},
+		{"PRTF,perf,tran,past,pssv masc,sing,nomn", "Vmps-smpfpn"},
+		{"GRND,impf,intr pres", "Vmgp-----e"},
+		{"NPRO,1per sing,nomn", "P-1-sn-n"},
+		{"ADJF,Apro,Dmns masc,sing,nomn", "Pd-msn-a"},
+		{"ADJF,Anum masc,sing,gent", "Momsgl"},
+		{"NUMR,inan accs", "Mc--al"},
+		{"ADVB", "R"},
+		{"PREP", "Sp"},
+		{"PNCT", ""},
+	}
+	for _, tt := range tests {
+		if msd := LookupTag(tt.tag).MSD(); msd != tt.msd {
+			t.Errorf("LookupTag(%q).MSD(): want %q, got %q", tt.tag, tt.msd, msd)
+		}
+	}
+}
+
+func TestFromMSD(t *testing.T) {
+	needDict(t)
+	for _, tag := range []string{
+		"NOUN,inan,masc sing,nomn",
+		"VERB,impf,tran sing,3per,pres,indc",
+		"PRTF,perf,tran,past,pssv masc,sing,nomn",
+		"ADJF,Qual femn,sing,nomn",
+		"ADJF,Apro,Dmns masc,sing,nomn",
+		"PREP",
+	} {
+		want := LookupTag(tag)
+		got := FromMSD(want.MSD())
+		found := false
+		for _, g := range got {
+			found = found || g.Equal(want)
+		}
+		if !found {
+			t.Errorf("FromMSD(%q): want %s among %v", want.MSD(), tag, got)
+		}
+	}
+	// c is Ms-f for the words other than nouns
+	for _, want := range matchingTags("", []string{"Ms-f"}) {
+		if want.set.Has(grammemeIndex["NOUN"]) {
+			continue
+		}
+		found := false
+		for _, g := range FromMSD(want.MSD()) {
+			found = found || g.Equal(want)
+		}
+		if !found {
+			t.Errorf("FromMSD(%q): want %s", want.MSD(), want)
+		}
+		break
+	}
+	for _, msd := range []string{"", "Z", "V"} {
+		if got := FromMSD(msd); got != nil {
+			t.Errorf("FromMSD(%q): want nil, got %v", msd, got)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"sort"
+	"strings"
+)
+
+// the Mystem parts of speech for the OpenCorpora ones
+var mystemPOS = map[string]string{
+	"NOUN": "S",
+	"ADJF": "A",
+	"ADJS": "A",
+	"COMP": "A",
+	"VERB": "V",
+	"INFN": "V",
+	"PRTF": "V",
+	"PRTS": "V",
+	"GRND": "V",
+	"NUMR": "NUM",
+	"ADVB": "ADV",
+	"NPRO": "SPRO",
+	"PRED": "ADV",
+	"PREP": "PR",
+	"CONJ": "CONJ",
+	"PRCL": "PART",
+	"INTJ": "INTJ",
+}
+
+type mystemGrammeme struct {
+	name      string
+	lex, infl int // the positions in the lexical (0 if inflectional) and the inflectional parts
+}
+
+// the Mystem grammemes for the OpenCorpora ones, ordered as Mystem prints them
+var mystemGrammemes = map[string]mystemGrammeme{
+	// the lexical grammemes
+	"Geox": {"гео", 1, 1},
+	"Name": {"имя", 1, 1},
+	"Patr": {"отч", 1, 1},
+	"Surn": {"фам", 1, 1},
+	"Abbr": {"сокр", 2, 2},
+	"Init": {"сокр", 2, 2},
+	"Prnt": {"вводн", 2, 2},
+	"masc": {"муж", 3, 7},
+	"femn": {"жен", 3, 7},
+	"neut": {"сред", 3, 7},
+	"GNdr": {"мж", 3, 7},
+	"Ms-f": {"мж", 3, 7},
+	"anim": {"од", 4, 10},
+	"inan": {"неод", 4, 10},
+	"perf": {"сов", 5, 5},
+	"impf": {"несов", 5, 5},
+	"tran": {"пе", 6, 6},
+	"intr": {"нп", 6, 6},
+	"Infr": {"разг", 7, 7},
+	"Slng": {"разг", 7, 7},
+	"Obsc": {"обсц", 7, 7},
+	"Arch": {"устар", 7, 7},
+	"Dist": {"искаж", 7, 7},
+	"Erro": {"искаж", 7, 7},
+
+	// the inflectional grammemes
+	"past": {"прош", 0, 1},
+	"pres": {"непрош", 0, 1},
+	"futr": {"непрош", 0, 1},
+	"nomn": {"им", 0, 2},
+	"gent": {"род", 0, 2},
+	"gen1": {"род", 0, 2},
+	"gen2": {"парт", 0, 2},
+	"datv": {"дат", 0, 2},
+	"accs": {"вин", 0, 2},
+	"acc2": {"вин", 0, 2},
+	"ablt": {"твор", 0, 2},
+	"loct": {"пр", 0, 2},
+	"loc1": {"пр", 0, 2},
+	"loc2": {"местн", 0, 2},
+	"voct": {"зват", 0, 2},
+	"sing": {"ед", 0, 3},
+	"plur": {"мн", 0, 3},
+	"COMP": {"срав", 0, 4},
+	"Supr": {"прев", 0, 4},
+	"indc": {"изъяв", 0, 5},
+	"impr": {"пов", 0, 5},
+	"INFN": {"инф", 0, 5},
+	"PRTF": {"прич", 0, 5},
+	"PRTS": {"прич", 0, 5},
+	"GRND": {"деепр", 0, 5},
+	"ADJF": {"полн", 0, 6},
+	"ADJS": {"кр", 0, 6},
+	"1per": {"1-л", 0, 8},
+	"2per": {"2-л", 0, 8},
+	"3per": {"3-л", 0, 8},
+	"actv": {"действ", 0, 9},
+	"pssv": {"страд", 0, 9},
+}
+
+// Mystem converts the tag to the grammatical information printed by
+// Yandex Mystem, e.g. "S,жен,од=им,ед" for "NOUN,anim,femn sing,nomn":
+// the part of speech and the lexical grammemes, "=" and the inflectional
+// grammemes. The adjectival pronouns (Apro) become APRO, the ordinal numerals
+// (Anum) ANUM, the participles, gerunds and infinitives V with прич, деепр
+// and инф. Mystem has no future tense and no predicatives: the present and
+// the future tenses of the verbs become непрош (наст for the participles and
+// the gerunds) and the predicatives become ADV. The tags of the punctuation,
+// numbers and non-Cyrillic words have no Mystem counterpart and give "".
+func (t Tag) Mystem() string {
+	lexical, inflectional := t.str, ""
+	if i := strings.IndexByte(t.str, ' '); i >= 0 {
+		lexical, inflectional = t.str[:i], t.str[i+1:]
+	}
+	names := splitTag(lexical)
+	if len(names) == 0 {
+		return ""
+	}
+	pos := names[0]
+	mpos, ok := mystemPOS[pos]
+	if !ok {
+		return ""
+	}
+	for _, name := range names[1:] {
+		switch name {
+		case "Apro":
+			if mpos == "A" {
+				mpos = "APRO"
+			} else if mpos == "ADV" {
+				mpos = "ADVPRO"
+			}
+		case "Anum":
+			if mpos == "A" {
+				mpos = "ANUM"
+			}
+		}
+	}
+
+	var lex, infl []mystemGrammeme
+	add := func(gs []mystemGrammeme, g mystemGrammeme) []mystemGrammeme {
+		for _, x := range gs {
+			if x.name == g.name {
+				return gs
+			}
+		}
+		return append(gs, g)
+	}
+	// the form of the verb and the full or short form of the adjective
+	switch pos {
+	case "INFN", "GRND", "COMP", "ADJF", "ADJS":
+		infl = add(infl, mystemGrammemes[pos])
+	case "PRTF":
+		infl = add(add(infl, mystemGrammemes[pos]), mystemGrammemes["ADJF"])
+	case "PRTS":
+		infl = add(add(infl, mystemGrammemes[pos]), mystemGrammemes["ADJS"])
+	}
+	// the gender and the animacy are lexical for the nouns only,
+	// where they come before the space in the tag
+	nlex := len(names)
+	for i, name := range append(names, splitTag(inflectional)...) {
+		g, ok := mystemGrammemes[name]
+		switch {
+		case !ok || i == 0:
+		case g.lex > 0 && (i < nlex || g.lex == g.infl):
+			lex = add(lex, g)
+		case pos == "PRED":
+			// the tense of the predicatives
+		case name == "pres" && (pos == "PRTF" || pos == "PRTS" || pos == "GRND"):
+			g.name = "наст"
+			infl = add(infl, g)
+		default:
+			infl = add(infl, g)
+		}
+	}
+	sort.SliceStable(lex, func(i, j int) bool { return lex[i].lex < lex[j].lex })
+	sort.SliceStable(infl, func(i, j int) bool { return infl[i].infl < infl[j].infl })
+
+	var sb strings.Builder
+	sb.WriteString(mpos)
+	for _, g := range lex {
+		sb.WriteByte(',')
+		sb.WriteString(g.name)
+	}
+	sb.WriteByte('=')
+	for i, g := range infl {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(g.name)
+	}
+	return sb.String()
+}
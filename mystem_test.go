@@ -0,0 +1,50 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import "testing"
+
+func TestTagMystem(t *testing.T) {
+	tests := []struct {
+		tag, gr string
+	}{
+		{"NOUN,anim,femn sing,nomn", "S,жен,од=им,ед"},
+		{"NOUN,anim,masc,Name sing,ablt", "S,имя,муж,од=твор,ед"},
+		{"NOUN,inan,masc sing,gen2", "S,муж,неод=парт,ед"},
+		{"NOUN,anim,Ms-f sing,nomn", "S,мж,од=им,ед"},
+		{"ADJF,Qual femn,sing,nomn", "A=им,ед,полн,жен"},
+		{"ADJF,Qual plur,accs,anim", "A=вин,мн,полн,од"},
+		{"ADJS,Qual neut,sing", "A=ед,кр,сред"},
+		{"ADJF,Apro,Subx plur,nomn", "APRO=им,мн,полн"},
+		{"ADJF,Anum masc,sing,gent", "ANUM=род,ед,полн,муж"},
+		{"COMP,Qual", "A=срав"},
+		{"VERB,impf,tran sing,3per,pres,indc", "V,несов,пе=непрош,ед,изъяв,3-л"},
+		{"VERB,perf,intr femn,sing,past,indc", "V,сов,нп=прош,ед,изъяв,жен"},
+		{"VERB,perf,tran plur,impr,excl", "V,сов,пе=мн,пов"},
+		{"INFN,perf,intr", "V,сов,нп=инф"},
+		{"GRND,impf,intr pres", "V,несов,нп=наст,деепр"},
+		{"PRTF,perf,tran,past,pssv masc,sing,nomn", "V,сов,пе=прош,им,ед,прич,полн,муж,страд"},
+		{"PRTS,impf,tran,pres,pssv femn,sing", "V,несов,пе=наст,ед,прич,кр,жен,страд"},
+		{"NPRO,masc,3per sing,nomn", "SPRO,муж=им,ед,3-л"},
+		{"PRED,pres", "ADV="},
+		{"PREP", "PR="},
+		{"PNCT", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if gr := LookupTag(tt.tag).Mystem(); gr != tt.gr {
+			t.Errorf("LookupTag(%q).Mystem(): want %s, got %s", tt.tag, tt.gr, gr)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrTooManyNodes is returned by MatchPattern when the walk of the dictionary
+// would visit more nodes than PatternQuery.MaxNodes allows.
+var ErrTooManyNodes = errors.New("pattern search visits too many dictionary nodes")
+
+// PatternQuery is a query of MatchPattern.
+type PatternQuery struct {
+	// Pattern is the (lowercase) word, where ? matches any letter
+	// and * matches any (possibly empty) sequence of letters,
+	// e.g. к?ш?а or *кот*. As in Parse, е matches ё as well.
+	Pattern  string
+	MinLen   int // the minimum length of the words, in letters
+	MaxLen   int // the maximum length of the words, in letters; 0 means no limit
+	Limit    int // the maximum number of the words; 0 means no limit
+	MaxNodes int // the maximum number of the dictionary nodes to visit; 0 means no limit
+	Filter   WordFilter
+}
+
+// MatchPattern returns the dictionary words matching the pattern
+// (all their forms matching the filter) in the dictionary order.
+// The letters of the pattern prune the walk of the dictionary, so the
+// patterns starting with letters are fast, while the ones starting with *
+// walk the whole dictionary, which takes a few seconds, even if nothing
+// matches; MaxNodes and ctx bound the walk. It fails if a grammeme of
+// the filter is unknown, if the walk exceeds MaxNodes (ErrTooManyNodes)
+// or if ctx is cancelled (ctx.Err()).
+func MatchPattern(ctx context.Context, q PatternQuery) ([]DictEntry, error) {
+	if probDAWG == nil {
+		panic("not initialized; call Init or InitWith")
+	}
+	m, err := newWordMatcher(q.Filter)
+	if err != nil {
+		return nil, err
+	}
+	p := &patternMatcher{
+		ctx:     ctx,
+		d:       wordsDAWG,
+		m:       m,
+		pattern: []rune(strings.ToLower(q.Pattern)),
+		q:       q,
+	}
+	start := p.statesAt(0)
+	start[0] = true
+	p.closure(start)
+	p.walk(0, 0)
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.found, nil
+}
+
+// patternMatcher walks the dawg keeping the set of the positions in the pattern
+// reachable after each letter of the current key (a nondeterministic automaton
+// simulated by the sets), and prunes the branches where the set is empty.
+type patternMatcher struct {
+	ctx     context.Context
+	d       *dawg
+	m       wordMatcher
+	pattern []rune
+	q       PatternQuery
+	key     []byte
+	states  [][]bool // states[k] is the set after k letters of the key, reused for the keys of the same length
+	depth   int      // the number of the letters of the key
+	nodes   int      // the number of the visited nodes
+	words   int      // the number of the found words
+	found   []DictEntry
+	err     error
+}
+
+// statesAt returns the cleared set for the given number of letters.
+func (p *patternMatcher) statesAt(depth int) []bool {
+	for len(p.states) <= depth {
+		p.states = append(p.states, make([]bool, len(p.pattern)+1))
+	}
+	s := p.states[depth]
+	for i := range s {
+		s[i] = false
+	}
+	return s
+}
+
+// closure adds to the set the positions reachable by matching * with nothing.
+func (p *patternMatcher) closure(s []bool) {
+	for i, r := range p.pattern {
+		if s[i] && r == '*' {
+			s[i+1] = true
+		}
+	}
+}
+
+// nextStates sets the states after the letter r and reports
+// whether the set is not empty.
+func (p *patternMatcher) nextStates(r rune) bool {
+	prev := p.states[p.depth]
+	s := p.statesAt(p.depth + 1)
+	empty := true
+	for i, pr := range p.pattern {
+		if !prev[i] {
+			continue
+		}
+		next := i
+		switch {
+		case pr == '*':
+		case pr == '?' || pr == r || pr == 'е' && r == 'ё':
+			next = i + 1
+		default:
+			continue
+		}
+		s[next] = true
+		empty = false
+	}
+	if empty {
+		return false
+	}
+	p.closure(s)
+	return true
+}
+
+// done reports whether the walk must stop: enough words have been found,
+// the node budget is exceeded or the context is cancelled.
+func (p *patternMatcher) done() bool {
+	if p.err != nil || p.q.Limit > 0 && p.words >= p.q.Limit {
+		return true
+	}
+	if p.nodes%ctxCheckInterval == 0 {
+		p.err = p.ctx.Err()
+	}
+	if p.nodes++; p.err == nil && p.q.MaxNodes > 0 && p.nodes > p.q.MaxNodes {
+		p.err = ErrTooManyNodes
+	}
+	return p.err != nil
+}
+
+// walk visits the children of the node; runeStart is the position
+// in the key where the current (possibly incomplete) letter starts.
+func (p *patternMatcher) walk(index uint32, runeStart int) {
+	dict, g := p.d.Dict, p.d.Guide
+	for label := g.child(index); label != 0 && !p.done(); {
+		child := dict.followByte(label, index)
+		if child == 0 {
+			return
+		}
+		if label != payloadSeparator {
+			p.key = append(p.key, label)
+			if utf8.FullRune(p.key[runeStart:]) {
+				r, _ := utf8.DecodeRune(p.key[runeStart:])
+				// the key has p.depth+1 letters with r
+				if n := p.depth + 1; (p.q.MaxLen <= 0 || n <= p.q.MaxLen) && p.nextStates(r) {
+					p.depth++
+					if p.states[p.depth][len(p.pattern)] && n >= p.q.MinLen && dict.followByte(payloadSeparator, child) != 0 {
+						found := len(p.found)
+						p.found = p.m.appendEntries(p.found, string(p.key))
+						if len(p.found) > found {
+							p.words++
+						}
+					}
+					p.walk(child, len(p.key))
+					p.depth--
+				}
+			} else {
+				p.walk(child, runeStart)
+			}
+			p.key = p.key[:len(p.key)-1]
+		}
+		label = g.sibling(child)
+	}
+}
@@ -0,0 +1,77 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMatchPattern(t *testing.T) {
+	words := func(q PatternQuery) map[string]bool {
+		entries, err := MatchPattern(context.Background(), q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := map[string]bool{}
+		for _, e := range entries {
+			found[e.Word] = true
+		}
+		return found
+	}
+
+	found := words(PatternQuery{Pattern: "?ам?", Filter: WordFilter{Grammemes: []string{"NOUN", "sing", "nomn"}}})
+	if !found["мама"] || !found["рама"] || found["маме"] {
+		t.Errorf("?ам?: unexpected words %v", found)
+	}
+
+	found = words(PatternQuery{Pattern: "*ам*", MinLen: 4, MaxLen: 4})
+	for w := range found {
+		if len([]rune(w)) != 4 {
+			t.Errorf("*ам*: %s is not of length 4", w)
+		}
+	}
+	if !found["ежам"] {
+		t.Errorf("*ам*: ежам not found in %v", found)
+	}
+
+	if found = words(PatternQuery{Pattern: "еж"}); !found["ёж"] {
+		t.Errorf("еж: ёж not found in %v", found)
+	}
+
+	entries, err := MatchPattern(context.Background(), PatternQuery{Pattern: "*", Limit: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	distinct := map[string]bool{}
+	for _, e := range entries {
+		distinct[e.Word] = true
+	}
+	if len(distinct) != 3 {
+		t.Errorf("*: want 3 words, got %v", distinct)
+	}
+
+	if _, err := MatchPattern(context.Background(), PatternQuery{Pattern: "*", Filter: WordFilter{Grammemes: []string{"nosuchgrammeme"}}}); err == nil {
+		t.Error("want an error for an unknown grammeme")
+	}
+
+	if _, err := MatchPattern(context.Background(), PatternQuery{Pattern: "*ъъъ", MaxNodes: 10}); err != ErrTooManyNodes {
+		t.Errorf("*ъъъ with MaxNodes: want ErrTooManyNodes, got %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := MatchPattern(ctx, PatternQuery{Pattern: "*ъъъ"}); err != context.Canceled {
+		t.Errorf("*ъъъ with a cancelled context: want context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+)
+
+// probOverride, if not nil, takes precedence over probDAWG
+// for the words it contains.
+var probOverride *dawg
+
+// LoadProbabilities loads P(tag|word) from the file written by ProbTrainer
+// as an override layer: for the words present in the file, the loaded
+// probabilities replace the ones from the dictionary, and the other words
+// keep the probabilities of the dictionary.
+// It must not be called concurrently with the analysis functions.
+func LoadProbabilities(fn string) error {
+	if probDAWG == nil {
+		panic("not initialized; call Init or InitWith")
+	}
+	d, err := newDAWG(fn)
+	if err != nil {
+		return err
+	}
+	probOverride = d
+	dictCache.purge()
+	predictCache.purge()
+	return nil
+}
+
+// ProbTrainer estimates P(tag|word) from an annotated corpus.
+// The probabilities are estimated only for the ambiguous words,
+// i.e. the words having several analyses in the dictionary.
+type ProbTrainer struct {
+	// Alpha is the additive smoothing parameter: each analysis
+	// of the word gets Alpha extra occurrences.
+	Alpha float64
+
+	// MinCount is the minimum number of occurrences of a word
+	// for its probabilities to be estimated.
+	MinCount int
+
+	counts    map[string]map[string]int // word -> tag -> count
+	yoForms   map[string]map[string]bool
+	matched   int
+	unmatched int
+	unknown   map[string]int // unknown grammeme -> number of tokens
+}
+
+// NewProbTrainer returns a new trainer with Alpha = 0.5 and MinCount = 1.
+func NewProbTrainer() *ProbTrainer {
+	return &ProbTrainer{
+		Alpha:    0.5,
+		MinCount: 1,
+		counts:   make(map[string]map[string]int),
+		yoForms:  make(map[string]map[string]bool),
+		unknown:  make(map[string]int),
+	}
+}
+
+// Add counts the tokens of the annotated sentence. The token tags are
+// matched against the analyses returned by Parse regardless of the order
+// of the grammemes; the tokens without a tag or whose tag does not match
+// are matched by their UD part of speech and features converted with
+// Tag.UD. The tokens which do not match any analysis are skipped.
+// The tags with grammemes unknown to the dictionary (see UnknownGrammemes)
+// are not matched, since ignoring the unknown grammemes might match
+// a wrong analysis.
+func (t *ProbTrainer) Add(sentence []CorpusToken) {
+	for _, tok := range sentence {
+		if tok.Tag == "" && tok.UPOS == "" {
+			continue
+		}
+		tagKnown := true
+		if tok.Tag != "" {
+			for _, name := range unknownGrammemes(tok.Tag) {
+				t.unknown[name]++
+				tagKnown = false
+			}
+		}
+
+		form := strings.ToLower(tok.Form)
+		word := strings.Replace(form, "ё", "е", -1)
+		_, _, ids := parse(word)
+		tags := tagNames(ids)
+		if len(tags) < 2 {
+			continue
+		}
+
+		found := ""
+		if tok.Tag != "" && tagKnown {
+			want := LookupTag(tok.Tag)
+			for _, tag := range tags {
+				if LookupTag(tag).Equal(want) {
+					found = tag
+					break
+				}
+			}
+		}
+		if found == "" && tok.UPOS != "" {
+			found = matchUD(tags, tok.UPOS, tok.Feats)
+		}
+		if found == "" {
+			t.unmatched++
+			continue
+		}
+		t.matched++
+
+		counts := t.counts[word]
+		if counts == nil {
+			counts = make(map[string]int, len(tags))
+			for _, tag := range tags {
+				counts[tag] = 0
+			}
+			t.counts[word] = counts
+		}
+		counts[found]++
+
+		if form != word {
+			if t.yoForms[word] == nil {
+				t.yoForms[word] = make(map[string]bool)
+			}
+			t.yoForms[word][form] = true
+		}
+	}
+}
+
+// Stats returns the numbers of the ambiguous tokens that did
+// and did not match an analysis.
+func (t *ProbTrainer) Stats() (matched, unmatched int) {
+	return t.matched, t.unmatched
+}
+
+// UnknownGrammemes returns the grammemes of the token tags unknown
+// to the dictionary, with the numbers of the tokens having them.
+// Many of them mean that the corpus uses a different tag set.
+func (t *ProbTrainer) UnknownGrammemes() map[string]int {
+	m := make(map[string]int, len(t.unknown))
+	for name, n := range t.unknown {
+		m[name] = n
+	}
+	return m
+}
+
+// Write writes the estimated probabilities to w as an IntDAWG with the
+// keys "word:tag" and the values P(tag|word) * 1e6, in the same format
+// as p_t_given_w.intdawg of the dictionary. The probabilities are stored
+// for the word with ё replaced with е and for its forms with ё seen in
+// the corpus. The probabilities of the loaded dictionary are copied for
+// the words not estimated from the corpus, so the file can replace
+// p_t_given_w.intdawg without losing them.
+func (t *ProbTrainer) Write(w io.Writer) error {
+	type entry struct {
+		key   string
+		value uint32
+	}
+	var entries []entry
+	estimated := make(map[string]bool)
+	for word, counts := range t.counts {
+		total := 0
+		for _, c := range counts {
+			total += c
+		}
+		if total < t.MinCount {
+			continue
+		}
+
+		denom := float64(total) + t.Alpha*float64(len(counts))
+		forms := []string{word}
+		for form := range t.yoForms[word] {
+			forms = append(forms, form)
+		}
+		for tag, c := range counts {
+			p := (float64(c) + t.Alpha) / denom
+			v := uint32(p * 1e6)
+			if v == 0 {
+				continue
+			}
+			for _, form := range forms {
+				entries = append(entries, entry{form + ":" + tag, v})
+				estimated[form] = true
+			}
+		}
+	}
+
+	// the keys of the dictionary are "word:tag"
+	var c completer
+	c.init(probDAWG.Dict, probDAWG.Guide)
+	c.start(0, "")
+	for c.next() {
+		i := bytes.IndexByte(c.key, ':')
+		if i < 0 || estimated[string(c.key[:i])] {
+			continue
+		}
+		entries = append(entries, entry{string(c.key), probDAWG.Dict.value(c.lastIndex)})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+	b := newDAWGBuilder()
+	for _, e := range entries {
+		if err := b.add(e.key, e.value); err != nil {
+			return err
+		}
+	}
+	d, err := b.finish()
+	if err != nil {
+		return err
+	}
+	return d.writeTo(w)
+}
@@ -0,0 +1,105 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProbTrainer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "morph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// make the noun analysis of "стали" the most probable one
+	tr := NewProbTrainer()
+	for i := 0; i < 10; i++ {
+		tr.Add([]CorpusToken{{Form: "стали", Tag: "NOUN,inan,femn,sing,gent"}})
+	}
+	tr.Add([]CorpusToken{{Form: "стали", Tag: "NOUN,nosuchgrammeme"}})
+	// a tag with an unknown grammeme is not matched, even if it would
+	// match without the grammeme
+	tr.Add([]CorpusToken{{Form: "стали", Tag: "VERB,perf,intr,plur,past,indc,nosuchgrammeme"}})
+	if matched, unmatched := tr.Stats(); matched != 10 || unmatched != 2 {
+		t.Errorf("Stats: want 10 matched and 2 unmatched, got %d and %d", matched, unmatched)
+	}
+	if unknown := tr.UnknownGrammemes(); len(unknown) != 1 || unknown["nosuchgrammeme"] != 2 {
+		t.Errorf("UnknownGrammemes: want nosuchgrammeme in 2 tokens, got %v", unknown)
+	}
+
+	fn := filepath.Join(dir, "p_t_given_w.intdawg")
+	f, err := os.Create(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Write(f); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	// a word of the dictionary outside the corpus keeps its probabilities,
+	// both in the written file and with the file loaded
+	var c completer
+	c.init(probDAWG.Dict, probDAWG.Guide)
+	c.start(0, "")
+	var key string
+	for c.next() {
+		if !strings.HasPrefix(string(c.key), "стали:") {
+			key = string(c.key)
+			break
+		}
+	}
+	i := strings.IndexByte(key, ':')
+	if i < 0 {
+		t.Fatalf("no probabilities in the dictionary")
+	}
+	word, tag := key[:i], key[i+1:]
+	want := probability(word, tag)
+	d, err := newDAWG(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := d.Dict.find(key); float64(v)/1e6 != want {
+		t.Errorf("Write: want P(%s|%s) = %v copied from the dictionary, got %v", tag, word, want, float64(v)/1e6)
+	}
+
+	saved := probOverride
+	defer func() { probOverride = saved }()
+	if err := LoadProbabilities(fn); err != nil {
+		t.Fatal(err)
+	}
+	if got := probability(word, tag); got != want {
+		t.Errorf("LoadProbabilities: want P(%s|%s) = %v kept, got %v", tag, word, want, got)
+	}
+
+	// the fallback to the dictionary for the words missing in the loaded file
+	probOverride = buildTestDAWG(t, map[string]uint32{"стали:" + tag: 1})
+	if got := probability(word, tag); got != want {
+		t.Errorf("fallback: want P(%s|%s) = %v from the dictionary, got %v", tag, word, want, got)
+	}
+	if err := LoadProbabilities(fn); err != nil {
+		t.Fatal(err)
+	}
+
+	_, norms, tags := Parse("стали")
+	if len(tags) == 0 || norms[0] != "сталь" || !LookupTag(tags[0]).Equal(LookupTag("NOUN,inan,femn sing,gent")) {
+		t.Errorf("Parse(стали): want the genitive of сталь first, got %v %v", norms, tags)
+	}
+}
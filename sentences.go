@@ -0,0 +1,232 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Sentence is a sentence of a text.
+type Sentence struct {
+	Text   string
+	Start  int // byte offset of the sentence in the text
+	End    int // byte offset of the end of the sentence
+	Tokens []Token
+}
+
+// the abbreviations (lowercase, without the final period) which are usually
+// followed by a capitalized word or a number within a sentence
+var nonFinalAbbreviations = toSet(
+	"т", "т.е", "т.к", "т.н", "т.ч", "т.о", "напр", "см", "ср", "ок", "прим",
+	"г", "гг", "ул", "пр", "просп", "пер", "пл", "наб", "ш", "б", "бул", "д", "корп",
+	"кв", "обл", "р-н", "пос", "с", "дер", "ст", "м", "им", "о", "оз", "р",
+	"проф", "акад", "доц", "канд", "докт", "чл", "ген", "полк", "кап", "лейт",
+	"св", "г-н", "г-жа", "тов", "гр", "ред", "изд", "вып", "стр", "рис",
+	"табл", "гл", "разд", "п", "пп", "ч", "тт", "тыс", "млн", "млрд", "руб", "коп",
+)
+
+// the abbreviations which often end a sentence; the sentence ends after
+// them if the next word is capitalized
+var finalAbbreviations = toSet("т.д", "т.п", "др", "н.э", "etc")
+
+// the abbreviations which end a sentence if they follow a number
+// (в 1799 г., в XIX в., 5 тыс. руб.) and the next word is capitalized
+var numberAbbreviations = toSet(
+	"г", "гг", "в", "вв", "тыс", "млн", "млрд", "руб", "коп", "долл", "евро",
+	"км", "м", "см", "мм", "кг", "л", "шт", "ч", "мин", "сек",
+)
+
+func toSet(ss ...string) map[string]bool {
+	m := make(map[string]bool, len(ss))
+	for _, s := range ss {
+		m[s] = true
+	}
+	return m
+}
+
+// SplitSentences splits the text into sentences. A sentence ends with
+// a period, a question or exclamation mark or an ellipsis (and the closing
+// quotes and brackets after them) followed by a capitalized word, a number,
+// an opening quote or a dash, or with a blank line. The known abbreviations
+// (т. е., г., ул., и т. д.) and the initials (А. С. Пушкин), recognized
+// with the dictionary, do not end a sentence.
+func SplitSentences(text string) []Sentence {
+	if probDAWG == nil {
+		panic("not initialized; call Init or InitWith")
+	}
+
+	toks := Tokenize(text)
+	var sents []Sentence
+	start := 0
+	for i := range toks {
+		if i < len(toks)-1 && !sentenceEnds(text, toks, i) {
+			continue
+		}
+		first, last := toks[start], toks[i]
+		sents = append(sents, Sentence{
+			Text:   text[first.Start:last.End],
+			Start:  first.Start,
+			End:    last.End,
+			Tokens: toks[start : i+1 : i+1],
+		})
+		start = i + 1
+	}
+	return sents
+}
+
+// sentenceEnds reports whether the sentence ends with the token i,
+// which is not the last one.
+func sentenceEnds(text string, toks []Token, i int) bool {
+	next := toks[i+1]
+	if isBlankLine(text[toks[i].End:next.Start]) {
+		return true
+	}
+
+	tok := toks[i]
+	if tok.Kind != TokenPunct || isClosing(toks, i+1) {
+		return false
+	}
+	if isClosing(toks, i) {
+		// a quote or a bracket after the end of a sentence
+		j := i
+		for j > 0 && isClosing(toks, j) {
+			j--
+		}
+		if j == i || !isTerminalPunct(toks[j].Text) {
+			return false
+		}
+		tok = toks[j]
+		i = j
+	} else if !isTerminalPunct(tok.Text) {
+		return false
+	}
+
+	capitalized := startsSentence(next)
+	if tok.Text != "." || i == 0 || toks[i-1].End != tok.Start {
+		return capitalized
+	}
+	prev := toks[i-1]
+	if !isWordToken(prev) {
+		return capitalized
+	}
+
+	// the abbreviations made of several parts, e.g. т. е., are matched
+	// as a whole first
+	from := i - 1
+	for from >= 2 && from > i-6 && toks[from-1].Text == "." &&
+		toks[from-1].Start == toks[from-2].End && isWordToken(toks[from-2]) {
+		from -= 2
+	}
+	for j := from; j < i; j += 2 {
+		if j == i-1 && isCapitalLetter(prev.Text) {
+			break // an initial rather than an abbreviation
+		}
+		abbr := abbreviationAt(toks, j, i-1)
+		switch {
+		case numberAbbreviations[abbr] && j > 0 && isNumeral(toks[j-1]):
+			return capitalized
+		case nonFinalAbbreviations[abbr]:
+			return false
+		case finalAbbreviations[abbr]:
+			return capitalized
+		}
+	}
+
+	if isInitial(prev.Text) {
+		return false
+	}
+	return capitalized
+}
+
+// abbreviationAt returns the lowercase abbreviation formed by the word
+// tokens from..to separated with periods.
+func abbreviationAt(toks []Token, from, to int) string {
+	var sb strings.Builder
+	for j := from; j <= to; j += 2 {
+		if j > from {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(strings.ToLower(toks[j].Text))
+	}
+	return sb.String()
+}
+
+// isInitial reports whether the word is a capital letter the dictionary
+// knows as an initial of a name or a patronymic.
+func isInitial(word string) bool {
+	if !isCapitalLetter(word) {
+		return false
+	}
+	names := grammemeSet("Name", "Patr", "Init")
+	_, _, ids := parse(strings.ToLower(word))
+	for _, id := range ids {
+		if tagSets[id].Intersects(names) {
+			return true
+		}
+	}
+	return false
+}
+
+func isCapitalLetter(word string) bool {
+	r, size := utf8.DecodeRuneInString(word)
+	return size == len(word) && unicode.IsUpper(r)
+}
+
+func isWordToken(tok Token) bool {
+	return tok.Kind == TokenWord || tok.Kind == TokenLatin
+}
+
+// isNumeral reports whether the token is a number or a Roman numeral.
+func isNumeral(tok Token) bool {
+	if tok.Kind == TokenNumber {
+		return true
+	}
+	return tok.Kind == TokenLatin && strings.Trim(tok.Text, "IVXLCDM") == ""
+}
+
+func isTerminalPunct(s string) bool {
+	return strings.Trim(s, ".?!…") == ""
+}
+
+// isClosing reports whether the token j is a closing quote or bracket;
+// the straight double quote is taken as a closing one if it is attached
+// to the previous token.
+func isClosing(toks []Token, j int) bool {
+	switch toks[j].Text {
+	case "»", "”", ")", "]":
+		return true
+	case "\"":
+		return j > 0 && toks[j-1].End == toks[j].Start
+	}
+	return false
+}
+
+// startsSentence reports whether the token can start a sentence.
+func startsSentence(tok Token) bool {
+	switch tok.Kind {
+	case TokenNumber:
+		return true
+	case TokenPunct:
+		return strings.Contains("«\"“(—–-", tok.Text)
+	}
+	r, _ := utf8.DecodeRuneInString(tok.Text)
+	return unicode.IsUpper(r)
+}
+
+func isBlankLine(space string) bool {
+	i := strings.IndexByte(space, '\n')
+	return i != -1 && strings.IndexByte(space[i+1:], '\n') != -1
+}
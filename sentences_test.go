@@ -0,0 +1,47 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitSentences(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Это, т. е. пример, короткий. Второе предложение.", []string{"Это, т. е. пример, короткий.", "Второе предложение."}},
+		{"Он живёт в г. Москве на ул. Ленина. Да.", []string{"Он живёт в г. Москве на ул. Ленина.", "Да."}},
+		{"А. С. Пушкин родился в 1799 г. Он был поэтом.", []string{"А. С. Пушкин родился в 1799 г.", "Он был поэтом."}},
+		{"Купили яблоки, груши и т.д. Потом ушли домой!", []string{"Купили яблоки, груши и т.д.", "Потом ушли домой!"}},
+		{"Что? Где? Когда...", []string{"Что?", "Где?", "Когда..."}},
+		{"Он сказал: «Иди!» Потом ушёл... куда-то.", []string{"Он сказал: «Иди!»", "Потом ушёл... куда-то."}},
+		{"Первый абзац\n\nвторой абзац", []string{"Первый абзац", "второй абзац"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, s := range SplitSentences(tt.text) {
+			got = append(got, s.Text)
+			if s.Text != tt.text[s.Start:s.End] || s.Tokens[0].Start != s.Start || s.Tokens[len(s.Tokens)-1].End != s.End {
+				t.Errorf("SplitSentences(%q): wrong offsets in %+v", tt.text, s)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitSentences(%q):\nwant %q\n got %q", tt.text, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// SuffixIndex is an index of the dictionary words by their endings.
+// It keeps the reversed words sorted in memory, which takes about
+// 70 MB for the Russian dictionary; building it takes a few seconds.
+// The index is read-only and safe for concurrent use.
+type SuffixIndex struct {
+	words   []byte   // the reversed words, sorted
+	offsets []uint32 // the start of each word in words, and the end of the last one
+
+	// Stress returns the index (in runes) of the stressed vowel
+	// of the word, or -1 if it is unknown, for finding rhymes.
+	// If it is nil or returns -1, the stress is guessed.
+	Stress func(word string) int
+}
+
+// NewSuffixIndex builds the suffix index of the loaded dictionary.
+func NewSuffixIndex() *SuffixIndex {
+	if probDAWG == nil {
+		panic("not initialized; call Init or InitWith")
+	}
+	var words []byte
+	var offsets []uint32
+	var c completer
+	c.init(wordsDAWG.Dict, wordsDAWG.Guide)
+	c.start(0, "")
+	var last []byte
+	for c.next() {
+		// the keys are the words followed by the separator and the payload
+		i := bytes.IndexByte(c.key, payloadSeparator)
+		if i < 0 || bytes.Equal(c.key[:i], last) {
+			continue
+		}
+		last = append(last[:0], c.key[:i]...)
+		offsets = append(offsets, uint32(len(words)))
+		words = appendReversed(words, last)
+	}
+	offsets = append(offsets, uint32(len(words)))
+
+	// sort the words, copying them in the sorted order
+	n := len(offsets) - 1
+	order := make([]uint32, n)
+	for i := range order {
+		order[i] = uint32(i)
+	}
+	word := func(i uint32) []byte { return words[offsets[i]:offsets[i+1]] }
+	sort.Slice(order, func(i, j int) bool { return bytes.Compare(word(order[i]), word(order[j])) < 0 })
+	x := &SuffixIndex{
+		words:   make([]byte, 0, len(words)),
+		offsets: make([]uint32, 0, n+1),
+	}
+	for _, i := range order {
+		x.offsets = append(x.offsets, uint32(len(x.words)))
+		x.words = append(x.words, word(i)...)
+	}
+	x.offsets = append(x.offsets, uint32(len(x.words)))
+	return x
+}
+
+// appendReversed appends the runes of s to dst in the reverse order.
+func appendReversed(dst, s []byte) []byte {
+	for len(s) > 0 {
+		_, size := utf8.DecodeLastRune(s)
+		dst = append(dst, s[len(s)-size:]...)
+		s = s[:len(s)-size]
+	}
+	return dst
+}
+
+func (x *SuffixIndex) word(i int) []byte {
+	return x.words[x.offsets[i]:x.offsets[i+1]]
+}
+
+// EndsWith returns the dictionary words (all their forms matching
+// the filter) ending with the (lowercase) suffix, grouped by the words
+// in the order of their reversed spelling. As in Parse, the letter е
+// in the suffix matches ё in the dictionary. It fails if a grammeme
+// of the filter is unknown.
+func (x *SuffixIndex) EndsWith(suffix string, f WordFilter) ([]DictEntry, error) {
+	m, err := newWordMatcher(f)
+	if err != nil {
+		return nil, err
+	}
+	var res []DictEntry
+	rev := appendReversed(nil, []byte(suffix))
+	x.findReversed(0, len(x.offsets)-1, nil, rev, func(i int) {
+		res = m.appendEntries(res, string(appendReversed(nil, x.word(i))))
+	})
+	return res, nil
+}
+
+// findReversed calls fn for the words starting with the reversed suffix rev,
+// with the letters е possibly replaced with ё, in the range [lo, hi) of the
+// words starting with prefix. The spellings with ё are tried only while
+// there are words with them, so the number of the branches is bounded
+// by the number of the words rather than of the letters е.
+func (x *SuffixIndex) findReversed(lo, hi int, prefix, rev []byte, fn func(int)) {
+	if len(rev) == 0 {
+		for i := lo; i < hi; i++ {
+			fn(i)
+		}
+		return
+	}
+	_, size := utf8.DecodeRune(rev)
+	letters := [][]byte{rev[:size]}
+	if string(rev[:size]) == "е" {
+		letters = append(letters, []byte("ё"))
+	}
+	for _, letter := range letters {
+		p := append(prefix[:len(prefix):len(prefix)], letter...)
+		start := lo + sort.Search(hi-lo, func(i int) bool { return bytes.Compare(x.word(lo+i), p) >= 0 })
+		end := start + sort.Search(hi-start, func(i int) bool { return !bytes.HasPrefix(x.word(start+i), p) })
+		if start < end {
+			x.findReversed(start, end, p, rev[size:], fn)
+		}
+	}
+}
+
+// Rhymes returns the dictionary words (their forms matching the filter)
+// rhyming with the (lowercase) word: the ones with the same ending starting
+// with the stressed vowel, e.g. картошка and морошка for кошка. The stress
+// comes from the Stress function, if any, or is guessed: ё is stressed,
+// otherwise the penultimate vowel of the words ending in a vowel, й or ь and
+// the last vowel of the others, which is right for many words but far
+// from all. The word itself is not included.
+func (x *SuffixIndex) Rhymes(word string, f WordFilter) ([]DictEntry, error) {
+	ending := x.rhymeEnding(word)
+	if ending == "" {
+		return nil, nil
+	}
+	candidates, err := x.EndsWith(ending, f)
+	if err != nil {
+		return nil, err
+	}
+	var res []DictEntry
+	rhymes := make(map[string]bool)
+	for _, e := range candidates {
+		if e.Word == word {
+			continue
+		}
+		ok, seen := rhymes[e.Word]
+		if !seen {
+			ok = x.rhymeEnding(e.Word) == ending
+			rhymes[e.Word] = ok
+		}
+		if ok {
+			res = append(res, e)
+		}
+	}
+	return res, nil
+}
+
+// rhymeEnding returns the ending of the word starting with the stressed vowel,
+// or "" if the word has no vowels.
+func (x *SuffixIndex) rhymeEnding(word string) string {
+	stress := -1
+	if x.Stress != nil {
+		stress = x.Stress(word)
+	}
+	if stress < 0 {
+		stress = guessStress(word)
+	}
+	if stress < 0 {
+		return ""
+	}
+	rr := []rune(word)
+	if stress >= len(rr) {
+		return ""
+	}
+	return string(rr[stress:])
+}
+
+// guessStress guesses the index (in runes) of the stressed vowel of the word,
+// returning -1 if the word has no vowels.
+func guessStress(word string) int {
+	rr := []rune(word)
+	var vowels []int
+	for i, r := range rr {
+		if r == 'ё' {
+			return i
+		}
+		if strings.ContainsRune("аеиоуыэюя", r) {
+			vowels = append(vowels, i)
+		}
+	}
+	switch n := len(vowels); {
+	case n == 0:
+		return -1
+	case n == 1:
+		return vowels[0]
+	case strings.ContainsRune("аеиоуыэюяйь", rr[len(rr)-1]):
+		return vowels[n-2]
+	default:
+		return vowels[n-1]
+	}
+}
@@ -0,0 +1,91 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSuffixIndex(t *testing.T) {
+	x := NewSuffixIndex()
+
+	entries, err := x.EndsWith("ама", WordFilter{Grammemes: []string{"NOUN", "nomn"}, LemmasOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := map[string]bool{}
+	for _, e := range entries {
+		if e.Form != 0 || len(e.Word) < len("ама") || e.Word[len(e.Word)-len("ама"):] != "ама" {
+			t.Errorf("unexpected entry %+v", e)
+		}
+		found[e.Word] = true
+	}
+	for _, w := range []string{"мама", "рама"} {
+		if !found[w] {
+			t.Errorf("EndsWith(ама): %s not found", w)
+		}
+	}
+
+	entries, err = x.EndsWith("еж", WordFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found = map[string]bool{}
+	for _, e := range entries {
+		found[e.Word] = true
+	}
+	if !found["ёж"] {
+		t.Error("EndsWith(еж): ёж not found")
+	}
+
+	// the spellings with ё must not be enumerated up front
+	entries, err = x.EndsWith(strings.Repeat("е", 40), WordFilter{})
+	if err != nil || len(entries) != 0 {
+		t.Errorf("EndsWith(е...е): want no entries, got %v, %v", entries, err)
+	}
+
+	entries, err = x.Rhymes("мама", WordFilter{Grammemes: []string{"NOUN"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found = map[string]bool{}
+	for _, e := range entries {
+		found[e.Word] = true
+	}
+	if !found["рама"] || found["раме"] || found["мама"] {
+		t.Errorf("Rhymes(мама): unexpected rhymes %v", found)
+	}
+
+	if _, err := x.EndsWith("а", WordFilter{Grammemes: []string{"nosuchgrammeme"}}); err == nil {
+		t.Error("want an error for an unknown grammeme")
+	}
+}
+
+func TestGuessStress(t *testing.T) {
+	for _, tc := range []struct {
+		word string
+		want int
+	}{
+		{"кошка", 1},
+		{"кот", 1},
+		{"ёлочка", 0},
+		{"стать", 2},
+		{"гмм", -1},
+	} {
+		if got := guessStress(tc.word); got != tc.want {
+			t.Errorf("guessStress(%q) = %d, want %d", tc.word, got, tc.want)
+		}
+	}
+}
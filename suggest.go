@@ -0,0 +1,260 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// the costs of the edits; a plain edit costs 1
+const (
+	costYo            = 0.0 // е in place of ё, as Parse allows
+	costYoBack        = 0.2 // ё in place of е
+	costConfusion     = 0.5 // е/и, ь/ъ and similar
+	costDouble        = 0.5 // missing or extra doubled letter
+	costAdjacentKey   = 0.7
+	costTransposition = 0.8
+)
+
+var confusedLetters = []string{"еи", "ьъ", "оа", "ая", "ую", "ыи", "эе", "шщ"}
+
+var keyboardRows = []string{
+	"йцукенгшщзхъ",
+	"фывапролджэ",
+	"ячсмитьбю",
+}
+
+type keyPos struct{ row, col int }
+
+var keyPositions = func() map[rune]keyPos {
+	m := make(map[rune]keyPos)
+	for i, row := range keyboardRows {
+		for j, r := range []rune(row) {
+			m[r] = keyPos{i, j}
+		}
+	}
+	return m
+}()
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// substitutionCost returns the cost of typing q in place of the dictionary letter r.
+func substitutionCost(q, r rune) float64 {
+	if q == r {
+		return 0
+	}
+	if q == 'е' && r == 'ё' {
+		return costYo
+	}
+	if q == 'ё' && r == 'е' {
+		return costYoBack
+	}
+	for _, pair := range confusedLetters {
+		if strings.ContainsRune(pair, q) && strings.ContainsRune(pair, r) {
+			return costConfusion
+		}
+	}
+	pq, ok1 := keyPositions[q]
+	pr, ok2 := keyPositions[r]
+	if ok1 && ok2 && abs(pq.row-pr.row) <= 1 && abs(pq.col-pr.col) <= 1 {
+		return costAdjacentKey
+	}
+	return 1
+}
+
+// Suggestion is a dictionary word suggested as a correction.
+type Suggestion struct {
+	Word     string
+	Distance float64 // weighted edit distance, where a plain edit costs 1
+}
+
+// IsKnown reports whether the word is in the dictionary. The word is
+// lowercased, as in Suggest, and, as in Parse, the letter е in the word
+// matches ё in the dictionary.
+func IsKnown(word string) bool {
+	if probDAWG == nil {
+		panic("not initialized; call Init or InitWith")
+	}
+	var buf [4]similarKey
+	return len(wordsDAWG.similarKeys(buf[:0], strings.ToLower(word))) > 0
+}
+
+// Suggest returns the dictionary words within the weighted edit distance
+// maxEdits (1 or 2 are the sensible values) of the word, sorted by the
+// distance. Typical Russian confusions (е/ё/и, ь/ъ, doubled consonants),
+// adjacent keys of the ЙЦУКЕН layout and transpositions cost less than
+// a plain edit. The dictionary has no word frequencies, so the words at
+// the same distance are not ranked by frequency: the ones having P(tag|word)
+// in the dictionary go first, but these are only the ambiguous words seen
+// in the annotated corpus, and the rest are sorted alphabetically.
+// The word itself and its spellings with ё are not included.
+func Suggest(word string, maxEdits int) []Suggestion {
+	if probDAWG == nil {
+		panic("not initialized; call Init or InitWith")
+	}
+	sugs := suggest(wordsDAWG, strings.ToLower(word), float64(maxEdits))
+
+	hasProbs := make(map[string]bool)
+	for _, s := range sugs {
+		hasProbs[s.Word] = isAttested(s.Word)
+	}
+	sort.SliceStable(sugs, func(i, j int) bool {
+		a, b := sugs[i], sugs[j]
+		if a.Distance != b.Distance {
+			return a.Distance < b.Distance
+		}
+		if hasProbs[a.Word] != hasProbs[b.Word] {
+			return hasProbs[a.Word]
+		}
+		return a.Word < b.Word
+	})
+	return sugs
+}
+
+// isAttested reports whether the word has probabilities, i.e. it is
+// an ambiguous word seen in the annotated corpus. The unambiguous words
+// have no probabilities, however frequent they are.
+func isAttested(word string) bool {
+	for _, d := range []*dawg{probOverride, probDAWG} {
+		if d == nil {
+			continue
+		}
+		if index := d.Dict.follow(word, 0); index != 0 && d.Dict.followByte(':', index) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// suggester walks the dawg keeping a row of the weighted Damerau-Levenshtein
+// distance matrix for each letter of the current key (a Levenshtein automaton
+// simulated by the rows), and prunes the branches that cannot get within the
+// maximum distance.
+type suggester struct {
+	d     *dawg
+	query []rune
+	max   float64
+	key   []byte
+	runes []rune
+	rows  [][]float64 // rows[k] is the row after k letters of the key, reused for the keys of the same length
+	depth int         // the number of the letters of the key
+	found []Suggestion
+}
+
+func suggest(d *dawg, word string, max float64) []Suggestion {
+	s := &suggester{
+		d:     d,
+		query: []rune(word),
+		max:   max,
+	}
+	row := make([]float64, len(s.query)+1)
+	for j := 1; j < len(row); j++ {
+		row[j] = row[j-1] + s.deletionCost(j)
+	}
+	s.rows = append(s.rows, row)
+	s.walk(0, 0)
+	return s.found
+}
+
+// deletionCost returns the cost of the query letter j-1 missing in the key.
+func (s *suggester) deletionCost(j int) float64 {
+	if j >= 2 && s.query[j-1] == s.query[j-2] {
+		return costDouble
+	}
+	return 1
+}
+
+// insertionCost returns the cost of the extra key letter r.
+func (s *suggester) insertionCost(r rune) float64 {
+	if n := len(s.runes); n > 0 && s.runes[n-1] == r {
+		return costDouble
+	}
+	return 1
+}
+
+// nextRow computes the row after the letter r into rows[depth+1].
+func (s *suggester) nextRow(r rune) []float64 {
+	prev := s.rows[s.depth]
+	if len(s.rows) == s.depth+1 {
+		s.rows = append(s.rows, make([]float64, len(prev)))
+	}
+	row := s.rows[s.depth+1]
+	row[0] = prev[0] + s.insertionCost(r)
+	for j := 1; j < len(row); j++ {
+		c := prev[j] + s.insertionCost(r)
+		if v := row[j-1] + s.deletionCost(j); v < c {
+			c = v
+		}
+		if v := prev[j-1] + substitutionCost(s.query[j-1], r); v < c {
+			c = v
+		}
+		if n := len(s.runes); j >= 2 && n >= 1 && s.query[j-1] == s.runes[n-1] && s.query[j-2] == r {
+			if v := s.rows[s.depth-1][j-2] + costTransposition; v < c {
+				c = v
+			}
+		}
+		row[j] = c
+	}
+	return row
+}
+
+func minOf(row []float64) float64 {
+	m := row[0]
+	for _, v := range row[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+// walk visits the children of the node; runeStart is the position
+// in the key where the current (possibly incomplete) letter starts.
+func (s *suggester) walk(index uint32, runeStart int) {
+	dict, g := s.d.Dict, s.d.Guide
+	for label := g.child(index); label != 0; {
+		child := dict.followByte(label, index)
+		if child == 0 {
+			return
+		}
+		if label != payloadSeparator {
+			s.key = append(s.key, label)
+			if utf8.FullRune(s.key[runeStart:]) {
+				r, _ := utf8.DecodeRune(s.key[runeStart:])
+				row := s.nextRow(r)
+				if minOf(row) <= s.max {
+					s.depth++
+					s.runes = append(s.runes, r)
+					if dist := row[len(row)-1]; dist > 0 && dist <= s.max && dict.followByte(payloadSeparator, child) != 0 {
+						s.found = append(s.found, Suggestion{string(s.key), dist})
+					}
+					s.walk(child, len(s.key))
+					s.depth--
+					s.runes = s.runes[:len(s.runes)-1]
+				}
+			} else {
+				s.walk(child, runeStart)
+			}
+			s.key = s.key[:len(s.key)-1]
+		}
+		label = g.sibling(child)
+	}
+}
@@ -0,0 +1,66 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testWordsDAWG(t *testing.T, words ...string) *dawg {
+	kv := make(map[string]uint32)
+	for _, w := range words {
+		kv[w+string(payloadSeparator)+"AAAAAA=="] = 0
+	}
+	return buildTestDAWG(t, kv)
+}
+
+func TestSuggestDistances(t *testing.T) {
+	d := testWordsDAWG(t, "корова", "молоко", "привет", "ёж", "касса", "кот", "кит", "котик")
+	for _, tc := range []struct {
+		word string
+		max  float64
+		want []Suggestion
+	}{
+		{"карова", 1, []Suggestion{{"корова", costConfusion}}},
+		{"превет", 1, []Suggestion{{"привет", costConfusion}}},
+		{"каса", 1, []Suggestion{{"касса", costDouble}}},
+		{"окт", 1, []Suggestion{{"кот", costTransposition}}},
+		{"еж", 1, nil},
+		{"малако", 1, []Suggestion{{"молоко", 2 * costConfusion}}},
+		{"мылако", 1, nil},
+		{"кор", 1, []Suggestion{{"кот", costAdjacentKey}}},
+	} {
+		got := suggest(d, tc.word, tc.max)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("suggest(%q, %v): want %v, got %v", tc.word, tc.max, tc.want, got)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	sugs := Suggest("превет", 1)
+	if len(sugs) == 0 || sugs[0].Word != "привет" {
+		t.Errorf("Suggest(превет): want привет first, got %v", sugs)
+	}
+	if !IsKnown("еж") {
+		t.Error("IsKnown(еж): want true")
+	}
+	if !IsKnown("Ёж") {
+		t.Error("IsKnown(Ёж): want true")
+	}
+	if IsKnown("превет") {
+		t.Error("IsKnown(превет): want false")
+	}
+}
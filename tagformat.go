@@ -0,0 +1,149 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"os"
+	"strings"
+)
+
+// TagFormat is the format of the tags returned by the analysis functions.
+type TagFormat int
+
+const (
+	// TagInternal is the OpenCorpora format with Latin grammemes,
+	// e.g. "NOUN,anim,masc sing,nomn".
+	TagInternal TagFormat = iota
+
+	// TagExternal is the OpenCorpora format with Cyrillic grammemes,
+	// e.g. "СУЩ,од,мр ед,им", from gramtab-opencorpora-ext.json.
+	TagExternal
+)
+
+// Options configures the loading of the dictionary.
+type Options struct {
+	// TagFormat is the format of the tags returned by Parse, XParse
+	// and the other analysis functions. The internal format is always
+	// used for the tags stored in the files, e.g. in CoNLL-U.
+	TagFormat TagFormat
+}
+
+var (
+	tagFormat       TagFormat
+	extTags         []string // parallel to tags
+	extTagIndex     map[string]int
+	grammemeToExt   map[string]string
+	grammemeFromExt map[string]string
+)
+
+// loadExtTags loads the external tags; the file is optional
+// unless the external format is chosen.
+func loadExtTags(fn string, format TagFormat) error {
+	tagFormat = format
+	extTags, extTagIndex = nil, nil
+	grammemeToExt = make(map[string]string)
+	grammemeFromExt = make(map[string]string)
+
+	ext, err := loadStringArray(fn)
+	if err != nil {
+		if os.IsNotExist(err) && format == TagInternal {
+			return nil
+		}
+		return err
+	}
+
+	extTags = ext
+	extTagIndex = make(map[string]int, len(ext))
+	for i, tag := range ext {
+		extTagIndex[tag] = i
+		if i >= len(tags) {
+			continue
+		}
+		in, ex := splitTag(tags[i]), splitTag(tag)
+		if len(in) != len(ex) {
+			continue
+		}
+		for j := range in {
+			grammemeToExt[in[j]] = ex[j]
+			grammemeFromExt[ex[j]] = in[j]
+		}
+	}
+	return nil
+}
+
+// convertTag converts the grammemes of the tag with the map,
+// keeping the separators and the unknown grammemes.
+func convertTag(tag string, m map[string]string) string {
+	var sb strings.Builder
+	start := 0
+	for i := 0; i <= len(tag); i++ {
+		if i < len(tag) && tag[i] != ',' && tag[i] != ' ' {
+			continue
+		}
+		name := tag[start:i]
+		if g, ok := m[name]; ok {
+			name = g
+		}
+		sb.WriteString(name)
+		if i < len(tag) {
+			sb.WriteByte(tag[i])
+		}
+		start = i + 1
+	}
+	return sb.String()
+}
+
+// ExternalTag converts the tag in the internal format to the external one,
+// e.g. "NOUN,anim,masc sing,nomn" to "СУЩ,од,мр ед,им". The tags are
+// returned unchanged if the dictionary lacks gramtab-opencorpora-ext.json.
+func ExternalTag(tag string) string {
+	if extTags == nil {
+		return tag
+	}
+	if i, ok := tagIndex[tag]; ok && i < len(extTags) {
+		return extTags[i]
+	}
+	return convertTag(tag, grammemeToExt)
+}
+
+// InternalTag converts the tag in the external format to the internal one,
+// e.g. "СУЩ,од,мр ед,им" to "NOUN,anim,masc sing,nomn". The tags already
+// in the internal format are returned unchanged.
+func InternalTag(tag string) string {
+	if extTags == nil {
+		return tag
+	}
+	if i, ok := extTagIndex[tag]; ok && i < len(tags) {
+		return tags[i]
+	}
+	return convertTag(tag, grammemeFromExt)
+}
+
+// externalTags converts the tags in place to the chosen format.
+func externalTags(ts []string) []string {
+	if tagFormat == TagExternal {
+		for i, t := range ts {
+			ts[i] = ExternalTag(t)
+		}
+	}
+	return ts
+}
+
+// externalTag converts the tag to the chosen format.
+func externalTag(t string) string {
+	if tagFormat == TagExternal {
+		return ExternalTag(t)
+	}
+	return t
+}
@@ -0,0 +1,42 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import "testing"
+
+func TestConvertTag(t *testing.T) {
+	m := map[string]string{"NOUN": "СУЩ", "anim": "од", "sing": "ед"}
+	if got, want := convertTag("NOUN,anim,Xxxx sing", m), "СУЩ,од,Xxxx ед"; got != want {
+		t.Errorf("convertTag: want %q, got %q", want, got)
+	}
+}
+
+func TestExternalTag(t *testing.T) {
+	const in, ex = "NOUN,anim,masc sing,nomn", "СУЩ,од,мр ед,им"
+	if got := ExternalTag(in); got != ex {
+		t.Errorf("ExternalTag(%q): want %q, got %q", in, ex, got)
+	}
+	if got := InternalTag(ex); got != in {
+		t.Errorf("InternalTag(%q): want %q, got %q", ex, in, got)
+	}
+	if got := InternalTag(in); got != in {
+		t.Errorf("InternalTag(%q): want %q, got %q", in, in, got)
+	}
+	if !LookupTag(ex).Equal(LookupTag(in)) {
+		t.Errorf("LookupTag(%q) != LookupTag(%q)", ex, in)
+	}
+	if _, err := NewGrammemeSet("мн", "дт"); err != nil {
+		t.Errorf("NewGrammemeSet with external grammemes: %v", err)
+	}
+}
@@ -0,0 +1,324 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+)
+
+const maxGrammemes = 256
+
+var (
+	grammemeNames []string
+	grammemeIndex map[string]Grammeme
+	tagSets       []GrammemeSet  // parallel to tags
+	tagIndex      map[string]int // tag -> index in tags
+
+	nonproductiveSet GrammemeSet
+	featureSet       GrammemeSet
+	featureAliases   []grammemeAlias
+	caseSet          GrammemeSet
+)
+
+type grammemeAlias struct {
+	from, to Grammeme
+}
+
+// Grammeme is a grammeme (e.g. NOUN, nomn or plur) known to the loaded dictionary.
+type Grammeme uint8
+
+// String returns the name of the grammeme.
+func (g Grammeme) String() string {
+	return grammemeNames[g]
+}
+
+// LookupGrammeme returns the grammeme with the given name.
+func LookupGrammeme(name string) (Grammeme, bool) {
+	g, ok := grammemeIndex[name]
+	return g, ok
+}
+
+// GrammemeSet is a set of grammemes.
+type GrammemeSet [maxGrammemes / 64]uint64
+
+// NewGrammemeSet returns the set of the named grammemes;
+// the grammemes of the external tag format are accepted as well.
+func NewGrammemeSet(names ...string) (GrammemeSet, error) {
+	var s GrammemeSet
+	for _, name := range names {
+		g, ok := grammemeIndex[name]
+		if !ok {
+			g, ok = grammemeIndex[grammemeFromExt[name]]
+		}
+		if !ok {
+			return GrammemeSet{}, fmt.Errorf("unknown grammeme: %s", name)
+		}
+		s.Add(g)
+	}
+	return s, nil
+}
+
+// Add adds the grammeme to the set.
+func (s *GrammemeSet) Add(g Grammeme) {
+	s[g/64] |= 1 << (g % 64)
+}
+
+// Has reports whether the set contains the grammeme.
+func (s GrammemeSet) Has(g Grammeme) bool {
+	return s[g/64]&(1<<(g%64)) != 0
+}
+
+// HasAll reports whether the set contains all the grammemes of t.
+func (s GrammemeSet) HasAll(t GrammemeSet) bool {
+	for i := range s {
+		if s[i]&t[i] != t[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Intersects reports whether the sets have a grammeme in common.
+func (s GrammemeSet) Intersects(t GrammemeSet) bool {
+	for i := range s {
+		if s[i]&t[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Equal reports whether the sets are equal.
+func (s GrammemeSet) Equal(t GrammemeSet) bool {
+	return s == t
+}
+
+func (s GrammemeSet) intersect(t GrammemeSet) GrammemeSet {
+	for i := range s {
+		s[i] &= t[i]
+	}
+	return s
+}
+
+func (s GrammemeSet) union(t GrammemeSet) GrammemeSet {
+	for i := range s {
+		s[i] |= t[i]
+	}
+	return s
+}
+
+func (s GrammemeSet) minus(t GrammemeSet) GrammemeSet {
+	for i := range s {
+		s[i] &^= t[i]
+	}
+	return s
+}
+
+func (s GrammemeSet) count() int {
+	n := 0
+	for _, w := range s {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// Names returns the names of the grammemes in the set.
+func (s GrammemeSet) Names() []string {
+	var names []string
+	for i, name := range grammemeNames {
+		if s.Has(Grammeme(i)) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Tag is a grammatical tag pre-parsed into a set of grammemes.
+type Tag struct {
+	set GrammemeSet
+	str string
+}
+
+// LookupTag returns the pre-parsed tag for the tag string
+// as returned by Parse or XParse. The tags in the external format
+// are converted to the internal one, which String returns.
+func LookupTag(s string) Tag {
+	if i, ok := tagIndex[s]; ok {
+		return Tag{tagSets[i], tags[i]}
+	}
+	if i, ok := extTagIndex[s]; ok && i < len(tags) {
+		return Tag{tagSets[i], tags[i]}
+	}
+	s = InternalTag(s)
+	var set GrammemeSet
+	for _, name := range splitTag(s) {
+		if g, ok := grammemeIndex[name]; ok {
+			set.Add(g)
+		}
+	}
+	return Tag{set, s}
+}
+
+// String returns the tag as a string, e.g. "NOUN,anim,masc sing,nomn".
+func (t Tag) String() string {
+	return t.str
+}
+
+// Grammemes returns the set of the tag's grammemes.
+func (t Tag) Grammemes() GrammemeSet {
+	return t.set
+}
+
+// Has reports whether the tag contains the grammeme.
+func (t Tag) Has(g Grammeme) bool {
+	return t.set.Has(g)
+}
+
+// HasAll reports whether the tag contains all the grammemes of s.
+func (t Tag) HasAll(s GrammemeSet) bool {
+	return t.set.HasAll(s)
+}
+
+// Intersects reports whether the tag contains any of the grammemes of s.
+func (t Tag) Intersects(s GrammemeSet) bool {
+	return t.set.Intersects(s)
+}
+
+// Equal reports whether the tags consist of the same grammemes.
+func (t Tag) Equal(u Tag) bool {
+	return t.set == u.set
+}
+
+func splitTag(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+// unknownGrammemes returns the grammemes of the tag (in either
+// tag format) unknown to the loaded dictionary.
+func unknownGrammemes(tag string) []string {
+	var unknown []string
+	for _, name := range splitTag(InternalTag(tag)) {
+		if _, ok := grammemeIndex[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	return unknown
+}
+
+// tagNames returns the tags with the given indices in tags.
+func tagNames(ids []int) []string {
+	if ids == nil {
+		return nil
+	}
+	ts := make([]string, len(ids))
+	for i, id := range ids {
+		ts[i] = tags[id]
+	}
+	return ts
+}
+
+// grammemeSet returns the set of the named grammemes, ignoring the unknown ones.
+func grammemeSet(names ...string) GrammemeSet {
+	var s GrammemeSet
+	for _, name := range names {
+		if g, ok := grammemeIndex[name]; ok {
+			s.Add(g)
+		}
+	}
+	return s
+}
+
+// matchingTags returns the loaded tags with the part of speech pos
+// (any if empty) and all the named grammemes, the ones with the fewest
+// grammemes first, or nil if any of the grammemes is unknown.
+func matchingTags(pos string, names []string) []Tag {
+	if pos != "" {
+		names = append([]string{pos}, names...)
+	}
+	var want GrammemeSet
+	for _, name := range names {
+		g, ok := grammemeIndex[name]
+		if !ok {
+			return nil
+		}
+		want.Add(g)
+	}
+	var ts []Tag
+	for i, set := range tagSets {
+		if set.HasAll(want) {
+			ts = append(ts, Tag{set, tags[i]})
+		}
+	}
+	sort.SliceStable(ts, func(i, j int) bool { return ts[i].set.count() < ts[j].set.count() })
+	return ts
+}
+
+// indexTags registers the grammemes of the catalogue and
+// parses the loaded tags into grammeme sets.
+func indexTags() error {
+	grammemeNames = nil
+	grammemeIndex = make(map[string]Grammeme)
+	add := func(name string) (Grammeme, error) {
+		g, ok := grammemeIndex[name]
+		if !ok {
+			if len(grammemeNames) == maxGrammemes {
+				return 0, errors.New("too many grammemes")
+			}
+			g = Grammeme(len(grammemeNames))
+			grammemeNames = append(grammemeNames, name)
+			grammemeIndex[name] = g
+		}
+		return g, nil
+	}
+	for _, e := range grammemeEntries {
+		if _, err := add(e[0]); err != nil {
+			return err
+		}
+	}
+	tagSets = make([]GrammemeSet, len(tags))
+	tagIndex = make(map[string]int, len(tags))
+	for i, tag := range tags {
+		for _, name := range splitTag(tag) {
+			g, err := add(name)
+			if err != nil {
+				return err
+			}
+			tagSets[i].Add(g)
+		}
+		tagIndex[tag] = i
+	}
+	indexGrammemes()
+
+	nonproductiveSet = grammemeSet(nonproductiveGrammemes...)
+	aliases := grammemeSet(featureParentAliases...)
+	featureSet = childrenOf(posCategory)
+	for _, c := range featureCategories {
+		featureSet = featureSet.union(valuesOf(c))
+	}
+	featureSet = featureSet.minus(aliases)
+	featureAliases = nil
+	for _, name := range featureParentAliases {
+		if from, ok := grammemeIndex[name]; ok {
+			if to, ok := from.Parent(); ok {
+				featureAliases = append(featureAliases, grammemeAlias{from, to})
+			}
+		}
+	}
+	caseSet = valuesOf(caseCategory).minus(aliases)
+	return nil
+}
@@ -0,0 +1,77 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTag(t *testing.T) {
+	tag := LookupTag("NOUN,anim,masc sing,nomn")
+	if tag.String() != "NOUN,anim,masc sing,nomn" {
+		t.Errorf("String: got %q", tag.String())
+	}
+
+	nounNomn, err := NewGrammemeSet("NOUN", "nomn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tag.HasAll(nounNomn) {
+		t.Errorf("%v: want HasAll(NOUN, nomn)", tag)
+	}
+
+	verbs, _ := NewGrammemeSet("VERB", "INFN")
+	if tag.Intersects(verbs) {
+		t.Errorf("%v: want !Intersects(VERB, INFN)", tag)
+	}
+
+	plur, ok := LookupGrammeme("plur")
+	if !ok {
+		t.Fatal("LookupGrammeme(plur): not found")
+	}
+	if tag.Has(plur) {
+		t.Errorf("%v: want !Has(plur)", tag)
+	}
+
+	if !tag.Equal(LookupTag("NOUN,masc,anim nomn,sing")) {
+		t.Errorf("%v: want the tag with the reordered grammemes to be equal", tag)
+	}
+
+	names := tag.Grammemes().Names()
+	for _, name := range []string{"NOUN", "anim", "masc", "sing", "nomn"} {
+		found := false
+		for _, n := range names {
+			found = found || n == name
+		}
+		if !found {
+			t.Errorf("%v: Names() = %v, want %s in it", tag, names, name)
+		}
+	}
+	if len(names) != 5 {
+		t.Errorf("%v: Names() = %v, want 5 grammemes", tag, names)
+	}
+
+	if _, err := NewGrammemeSet("nosuchgrammeme"); err == nil {
+		t.Error("NewGrammemeSet(nosuchgrammeme): want an error")
+	}
+}
+
+func TestSimilarityFeatures(t *testing.T) {
+	a := similarityFeatures(LookupTag("NOUN,anim,masc,Name sing,loc1"))
+	b := similarityFeatures(LookupTag("NOUN,inan,femn sing,loct"))
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("similarityFeatures: want %v, got %v", b.Names(), a.Names())
+	}
+}
@@ -0,0 +1,316 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TokenKind is the kind of a token.
+type TokenKind int
+
+// The token kinds.
+const (
+	TokenWord   TokenKind = iota // a word with Cyrillic or other non-Latin letters
+	TokenNumber                  // digits, possibly with a decimal separator or a suffix (2,5; 90-х)
+	TokenPunct                   // punctuation or a symbol
+	TokenLatin                   // a word of Latin letters
+	TokenURL
+	TokenEmail
+)
+
+var tokenKindNames = [...]string{"word", "number", "punct", "latin", "url", "email"}
+
+func (k TokenKind) String() string {
+	if k < 0 || int(k) >= len(tokenKindNames) {
+		return "unknown"
+	}
+	return tokenKindNames[k]
+}
+
+// Token is a token of a text.
+type Token struct {
+	Text      string
+	Kind      TokenKind
+	Start     int // byte offset of the token in the text
+	End       int // byte offset of the end of the token
+	RuneStart int // rune offset of the token in the text
+	RuneEnd   int // rune offset of the end of the token
+}
+
+// Tokenize splits the text into tokens; the white space is skipped.
+// The words with hyphens (интернет-магазин, смотри-ка, по-западному)
+// and apostrophes (д'Артаньян) inside are kept as single tokens, as XParse
+// understands such words; the letters with the combining stress marks
+// are kept within the words.
+func Tokenize(text string) []Token {
+	var toks []Token
+	runes := 0
+	noEmail := 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if unicode.IsSpace(r) {
+			i += size
+			runes++
+			continue
+		}
+		end, kind := scanToken(text, i, &noEmail)
+		n := utf8.RuneCountInString(text[i:end])
+		toks = append(toks, Token{text[i:end], kind, i, end, runes, runes + n})
+		i = end
+		runes += n
+	}
+	return toks
+}
+
+// AnalyzedToken is a token with the analyses of its text
+// as returned by XParse. Only the word tokens are analyzed.
+type AnalyzedToken struct {
+	Token
+	Result
+}
+
+// Analyze splits the text into tokens and analyzes the word tokens with
+// XParse. The words are lowercased and the stress marks are removed
+// before the analysis; the resulting word is stored in Result.Word.
+func Analyze(text string) []AnalyzedToken {
+	if probDAWG == nil {
+		panic("not initialized; call Init or InitWith")
+	}
+	toks := Tokenize(text)
+	res := make([]AnalyzedToken, len(toks))
+	for i, tok := range toks {
+		res[i].Token = tok
+		if tok.Kind != TokenWord {
+			continue
+		}
+		word := NormalizeWord(tok.Text)
+		words, norms, tags := XParse(word)
+		res[i].Result = Result{word, words, norms, tags}
+	}
+	return res
+}
+
+// NormalizeWord lowercases the word, removes the stress marks, composes
+// й and ё written with the combining marks and replaces the typographic
+// hyphens and apostrophes with the ASCII ones, as Analyze does before
+// the analysis.
+func NormalizeWord(s string) string {
+	rr := make([]rune, 0, len(s))
+	for _, r := range strings.ToLower(s) {
+		n := len(rr)
+		switch {
+		case r == '\u0306' && n > 0 && rr[n-1] == 'и':
+			rr[n-1] = 'й'
+		case r == '\u0308' && n > 0 && rr[n-1] == 'е':
+			rr[n-1] = 'ё'
+		case unicode.Is(unicode.Mn, r):
+		case isHyphen(r):
+			rr = append(rr, '-')
+		case isApostrophe(r):
+			rr = append(rr, '\'')
+		default:
+			rr = append(rr, r)
+		}
+	}
+	return string(rr)
+}
+
+func isHyphen(r rune) bool {
+	return r == '-' || r == '\u2010'
+}
+
+func isApostrophe(r rune) bool {
+	return r == '\'' || r == '’' || r == 'ʼ'
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.Is(unicode.Mn, r)
+}
+
+// runeAt returns the rune at the byte offset i, or utf8.RuneError at the end of the text.
+func runeAt(text string, i int) (rune, int) {
+	if i >= len(text) {
+		return utf8.RuneError, 0
+	}
+	return utf8.DecodeRuneInString(text[i:])
+}
+
+// scanToken returns the end of the token starting at i and its kind.
+// The noEmail offset is kept by scanEmail between the calls.
+func scanToken(text string, i int, noEmail *int) (int, TokenKind) {
+	if end := scanURL(text, i); end > i {
+		return end, TokenURL
+	}
+	if end := scanEmail(text, i, noEmail); end > i {
+		return end, TokenEmail
+	}
+
+	r, size := runeAt(text, i)
+	switch {
+	case unicode.IsLetter(r):
+		return scanWord(text, i)
+	case unicode.IsDigit(r):
+		return scanNumber(text, i), TokenNumber
+	case strings.ContainsRune(".?!…", r):
+		end := i + size
+		for {
+			r, size := runeAt(text, end)
+			if size == 0 || !strings.ContainsRune(".?!…", r) {
+				break
+			}
+			end += size
+		}
+		return end, TokenPunct
+	}
+	return i + size, TokenPunct
+}
+
+func scanWord(text string, i int) (int, TokenKind) {
+	end := i
+	cyrillic, latin := false, false
+	for {
+		r, size := runeAt(text, end)
+		if size == 0 {
+			break
+		}
+		if isHyphen(r) || isApostrophe(r) {
+			if next, _ := runeAt(text, end+size); !unicode.IsLetter(next) {
+				break
+			}
+		} else if !isWordRune(r) {
+			break
+		}
+		cyrillic = cyrillic || unicode.Is(unicode.Cyrillic, r)
+		latin = latin || unicode.Is(unicode.Latin, r)
+		end += size
+	}
+	if latin && !cyrillic {
+		return end, TokenLatin
+	}
+	return end, TokenWord
+}
+
+func scanDigits(text string, i int) int {
+	for i < len(text) && '0' <= text[i] && text[i] <= '9' {
+		i++
+	}
+	return i
+}
+
+func scanNumber(text string, i int) int {
+	end := scanDigits(text, i)
+	if end == i {
+		// non-ASCII digits
+		for {
+			r, size := runeAt(text, end)
+			if size == 0 || !unicode.IsDigit(r) {
+				return end
+			}
+			end += size
+		}
+	}
+	// decimal separators and digit groups: 2,5; 1.000.000
+	for end+1 < len(text) && (text[end] == '.' || text[end] == ',') {
+		next := scanDigits(text, end+1)
+		if next == end+1 {
+			break
+		}
+		end = next
+	}
+	// a suffix after a hyphen: 5-й, 90-х
+	if r, size := runeAt(text, end); isHyphen(r) {
+		if next, _ := runeAt(text, end+size); unicode.IsLetter(next) {
+			end += size
+			for {
+				r, size := runeAt(text, end)
+				if size == 0 || !unicode.IsLetter(r) {
+					break
+				}
+				end += size
+			}
+		}
+	}
+	return end
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// the punctuation trimmed from the end of URLs and emails
+const trailingPunct = `.,;:!?'"»)]}`
+
+func scanURL(text string, i int) int {
+	s := text[i:]
+	var prefix string
+	for _, p := range []string{"http://", "https://", "www."} {
+		if hasPrefixFold(s, p) {
+			prefix = p
+			break
+		}
+	}
+	if prefix == "" {
+		return i
+	}
+	end := strings.IndexFunc(s, unicode.IsSpace)
+	if end == -1 {
+		end = len(s)
+	}
+	u := strings.TrimRight(s[:end], trailingPunct)
+	if len(u) == len(prefix) {
+		return i
+	}
+	return i + len(u)
+}
+
+func isEmailLocal(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		strings.IndexByte("._%+-", c) != -1
+}
+
+// scanEmail returns the end of the email starting at i, or i if there is
+// none. An email starting inside the local part of a failed one would end
+// at the same @, so the failure is recorded in noEmail: no email starts
+// before it. This way a long run of letters, digits and dots is scanned
+// once rather than once for every token in it.
+func scanEmail(text string, i int, noEmail *int) int {
+	if i < *noEmail {
+		return i
+	}
+	at := i
+	for at < len(text) && isEmailLocal(text[at]) {
+		at++
+	}
+	if at == i || at == len(text) || text[at] != '@' {
+		*noEmail = at
+		return i
+	}
+	end := at + 1
+	for {
+		r, size := runeAt(text, end)
+		if size == 0 || !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-') {
+			break
+		}
+		end += size
+	}
+	domain := strings.TrimRight(text[at+1:end], ".-")
+	if dot := strings.LastIndexByte(domain, '.'); dot <= 0 || dot == len(domain)-1 {
+		*noEmail = at
+		return i
+	}
+	return at + 1 + len(domain)
+}
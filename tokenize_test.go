@@ -0,0 +1,98 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string // text/kind
+	}{
+		{
+			"Интернет-магазин, смотри-ка, работает по-западному!",
+			[]string{"Интернет-магазин/word", ",/punct", "смотри-ка/word", ",/punct", "работает/word", "по-западному/word", "!/punct"},
+		},
+		{
+			"Ещё 2,5 кг в 90-х годах... Д'Артаньян - O'Neil",
+			[]string{"Ещё/word", "2,5/number", "кг/word", "в/word", "90-х/number", "годах/word", ".../punct", "Д'Артаньян/word", "-/punct", "O'Neil/latin"},
+		},
+		{
+			"Пишите на info@example.ru или см. https://example.com/a?b=1).",
+			[]string{"Пишите/word", "на/word", "info@example.ru/email", "или/word", "см/word", "./punct", "https://example.com/a?b=1/url", ")/punct", "./punct"},
+		},
+		{
+			"за\u0301мок, www.ya.ru, user@host — COVID-19",
+			[]string{"за\u0301мок/word", ",/punct", "www.ya.ru/url", ",/punct", "user/latin", "@/punct", "host/latin", "—/punct", "COVID/latin", "-/punct", "19/number"},
+		},
+		{
+			"a.b@host x.y@mail.ru",
+			[]string{"a/latin", "./punct", "b/latin", "@/punct", "host/latin", "x.y@mail.ru/email"},
+		},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, tok := range Tokenize(tt.text) {
+			got = append(got, fmt.Sprintf("%s/%s", tok.Text, tok.Kind))
+			if tt.text[tok.Start:tok.End] != tok.Text {
+				t.Errorf("Tokenize(%q): wrong byte offsets in %+v", tt.text, tok)
+			}
+			if string([]rune(tt.text)[tok.RuneStart:tok.RuneEnd]) != tok.Text {
+				t.Errorf("Tokenize(%q): wrong rune offsets in %+v", tt.text, tok)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q):\nwant %q\n got %q", tt.text, tt.want, got)
+		}
+	}
+}
+
+func TestNormalizeWord(t *testing.T) {
+	for in, want := range map[string]string{
+		"За\u0301мок":  "замок",
+		"Е\u0308лочка": "ёлочка",
+		"чаи\u0306":    "чай",
+		"Д’Артаньян":   "д'артаньян",
+		"кто\u2010то":  "кто-то",
+	} {
+		if got := NormalizeWord(in); got != want {
+			t.Errorf("NormalizeWord(%q): want %q, got %q", in, want, got)
+		}
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	toks := Analyze("Смотри-ка, 5 ежей!")
+	if len(toks) != 5 {
+		t.Fatalf("Analyze: want 5 tokens, got %d", len(toks))
+	}
+	for _, i := range []int{0, 3} {
+		tok := toks[i]
+		words, norms, tags := XParse(tok.Word)
+		want := Result{tok.Word, words, norms, tags}
+		if len(words) == 0 || !reflect.DeepEqual(tok.Result, want) {
+			t.Errorf("Analyze: token %q: want %v, got %v", tok.Text, want, tok.Result)
+		}
+	}
+	if toks[0].Word != "смотри-ка" {
+		t.Errorf("Analyze: want the word %q, got %q", "смотри-ка", toks[0].Word)
+	}
+	if toks[2].Kind != TokenNumber || len(toks[2].Words) != 0 {
+		t.Errorf("Analyze: want an unanalyzed number, got %+v", toks[2])
+	}
+}
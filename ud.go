@@ -0,0 +1,209 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"sort"
+	"strings"
+)
+
+// the Universal Dependencies parts of speech for the OpenCorpora ones
+var udPOS = map[string]string{
+	"NOUN": "NOUN",
+	"ADJF": "ADJ",
+	"ADJS": "ADJ",
+	"COMP": "ADJ",
+	"VERB": "VERB",
+	"INFN": "VERB",
+	"PRTF": "VERB",
+	"PRTS": "VERB",
+	"GRND": "VERB",
+	"NUMR": "NUM",
+	"ADVB": "ADV",
+	"NPRO": "PRON",
+	"PRED": "ADV",
+	"PREP": "ADP",
+	"CONJ": "CCONJ",
+	"PRCL": "PART",
+	"INTJ": "INTJ",
+	"PNCT": "PUNCT",
+	"NUMB": "NUM",
+	"ROMN": "NUM",
+	"LATN": "X",
+	"UNKN": "X",
+}
+
+// the Universal Dependencies features for the OpenCorpora grammemes
+var udFeatures = map[string][2]string{
+	"anim": {"Animacy", "Anim"},
+	"inan": {"Animacy", "Inan"},
+	"perf": {"Aspect", "Perf"},
+	"impf": {"Aspect", "Imp"},
+	"nomn": {"Case", "Nom"},
+	"gent": {"Case", "Gen"},
+	"gen1": {"Case", "Gen"},
+	"gen2": {"Case", "Par"},
+	"datv": {"Case", "Dat"},
+	"accs": {"Case", "Acc"},
+	"acc2": {"Case", "Acc"},
+	"ablt": {"Case", "Ins"},
+	"loct": {"Case", "Loc"},
+	"loc1": {"Case", "Loc"},
+	"loc2": {"Case", "Loc"},
+	"voct": {"Case", "Voc"},
+	"COMP": {"Degree", "Cmp"},
+	"Supr": {"Degree", "Sup"},
+	"masc": {"Gender", "Masc"},
+	"femn": {"Gender", "Fem"},
+	"neut": {"Gender", "Neut"},
+	"indc": {"Mood", "Ind"},
+	"impr": {"Mood", "Imp"},
+	"Anum": {"NumType", "Ord"},
+	"sing": {"Number", "Sing"},
+	"plur": {"Number", "Plur"},
+	"1per": {"Person", "1"},
+	"2per": {"Person", "2"},
+	"3per": {"Person", "3"},
+	"past": {"Tense", "Past"},
+	"pres": {"Tense", "Pres"},
+	"futr": {"Tense", "Fut"},
+	"ADJS": {"Variant", "Short"},
+	"PRTS": {"Variant", "Short"},
+	"VERB": {"VerbForm", "Fin"},
+	"INFN": {"VerbForm", "Inf"},
+	"PRTF": {"VerbForm", "Part"},
+	"GRND": {"VerbForm", "Conv"},
+	"actv": {"Voice", "Act"},
+	"pssv": {"Voice", "Pass"},
+	"Abbr": {"Abbr", "Yes"},
+	"Poss": {"Poss", "Yes"},
+}
+
+// UD converts the tag to the Universal Dependencies part of speech (UPOS)
+// and features (FEATS, "_" if there are none), following the OpenCorpora
+// to UD mapping used for the Russian UD treebanks. Note the part of speech
+// splits: the adjectival pronouns (Apro) become DET, with Poss=Yes for
+// the possessive ones (Poss, e.g. мой, твой), the proper names and
+// the geographical and organization names become PROPN, the ordinal numerals
+// (Anum) stay ADJ with NumType=Ord; the short forms get Variant=Short.
+// The noun pronouns (NPRO, e.g. он, кто, ничто) always become PRON, never
+// DET: OpenCorpora tags the determiner-like pronouns (e.g. этот, весь)
+// as the adjectival ones. The conjunctions always become CCONJ, as
+// the tag does not tell the subordinating ones.
+func (t Tag) UD() (upos, feats string) {
+	names := splitTag(t.str)
+	if len(names) == 0 {
+		return "X", "_"
+	}
+	pos := names[0]
+	upos, ok := udPOS[pos]
+	if !ok {
+		upos = "X"
+	}
+
+	features := make(map[string]string)
+	for _, name := range names {
+		if f, ok := udFeatures[name]; ok {
+			if _, dup := features[f[0]]; !dup {
+				features[f[0]] = f[1]
+			}
+		}
+		switch name {
+		case "Apro":
+			if upos == "ADJ" {
+				upos = "DET"
+			}
+		case "Name", "Surn", "Patr", "Geox", "Orgn", "Trad":
+			if upos == "NOUN" {
+				upos = "PROPN"
+			}
+		}
+	}
+	switch pos {
+	case "PRTS":
+		features["VerbForm"] = "Part"
+	case "ADJF", "ADJS":
+		if upos == "ADJ" && features["NumType"] == "" && features["Degree"] == "" {
+			features["Degree"] = "Pos"
+		}
+	}
+
+	if len(features) == 0 {
+		return upos, "_"
+	}
+	keys := make([]string, 0, len(features))
+	for k := range features {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return strings.ToLower(keys[i]) < strings.ToLower(keys[j]) })
+	var sb strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteByte('|')
+		}
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(features[k])
+	}
+	return upos, sb.String()
+}
+
+// parseFeats parses the FEATS column, e.g. "Case=Nom|Number=Sing".
+func parseFeats(feats string) map[string]string {
+	m := make(map[string]string)
+	if feats == "_" {
+		return m
+	}
+	for _, f := range strings.Split(feats, "|") {
+		if i := strings.IndexByte(f, '='); i > 0 {
+			m[f[:i]] = f[i+1:]
+		}
+	}
+	return m
+}
+
+// matchUD returns the tag agreeing with the UD part of speech and features
+// best, or "" if there is no such tag or several tags agree equally well.
+// The features missing in either the tag or feats are ignored.
+func matchUD(tags []string, upos, feats string) string {
+	want := parseFeats(feats)
+	best, bestScore, tie := "", -1, false
+	for _, tag := range tags {
+		u, f := LookupTag(tag).UD()
+		if u != upos {
+			continue
+		}
+		score := 0
+		for k, v := range parseFeats(f) {
+			if w, ok := want[k]; ok {
+				if w != v {
+					score = -1
+					break
+				}
+				score++
+			}
+		}
+		switch {
+		case score < 0 || tag == best:
+		case score > bestScore:
+			best, bestScore, tie = tag, score, false
+		case score == bestScore:
+			tie = true
+		}
+	}
+	if tie {
+		return ""
+	}
+	return best
+}
@@ -0,0 +1,51 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import "testing"
+
+func TestTagUD(t *testing.T) {
+	tests := []struct {
+		tag, upos, feats string
+	}{
+		{"PRTF,perf,tran,past,pssv masc,sing,nomn", "VERB", "Aspect=Perf|Case=Nom|Gender=Masc|Number=Sing|Tense=Past|VerbForm=Part|Voice=Pass"},
+		{"NOUN,anim,masc sing,nomn", "NOUN", "Animacy=Anim|Case=Nom|Gender=Masc|Number=Sing"},
+		{"NOUN,anim,masc,Name sing,ablt", "PROPN", "Animacy=Anim|Case=Ins|Gender=Masc|Number=Sing"},
+		{"ADJF,Apro,Subx plur,nomn", "DET", "Case=Nom|Number=Plur"},
+		// мой, твоя
+		{"ADJF,Apro,Poss masc,sing,nomn", "DET", "Case=Nom|Gender=Masc|Number=Sing|Poss=Yes"},
+		{"ADJF,Apro,Poss femn,sing,nomn", "DET", "Case=Nom|Gender=Fem|Number=Sing|Poss=Yes"},
+		// лисий
+		{"ADJF,Poss masc,sing,nomn", "ADJ", "Case=Nom|Degree=Pos|Gender=Masc|Number=Sing|Poss=Yes"},
+		{"ADJF,Qual femn,sing,nomn", "ADJ", "Case=Nom|Degree=Pos|Gender=Fem|Number=Sing"},
+		{"ADJF,Anum masc,sing,gent", "ADJ", "Case=Gen|Gender=Masc|Number=Sing|NumType=Ord"},
+		{"ADJS,Qual neut,sing", "ADJ", "Degree=Pos|Gender=Neut|Number=Sing|Variant=Short"},
+		{"PRTS,perf,past,pssv femn,sing", "VERB", "Aspect=Perf|Gender=Fem|Number=Sing|Tense=Past|Variant=Short|VerbForm=Part|Voice=Pass"},
+		{"VERB,impf,tran sing,3per,pres,indc", "VERB", "Aspect=Imp|Mood=Ind|Number=Sing|Person=3|Tense=Pres|VerbForm=Fin"},
+		{"INFN,perf,intr", "VERB", "Aspect=Perf|VerbForm=Inf"},
+		{"GRND,impf,intr pres", "VERB", "Aspect=Imp|Tense=Pres|VerbForm=Conv"},
+		{"NPRO,masc,3per,Anph sing,nomn", "PRON", "Case=Nom|Gender=Masc|Number=Sing|Person=3"},
+		{"COMP,Qual", "ADJ", "Degree=Cmp"},
+		{"NOUN,inan,masc sing,gen2", "NOUN", "Animacy=Inan|Case=Par|Gender=Masc|Number=Sing"},
+		{"CONJ", "CCONJ", "_"},
+		{"PNCT", "PUNCT", "_"},
+		{"", "X", "_"},
+	}
+	for _, tt := range tests {
+		upos, feats := LookupTag(tt.tag).UD()
+		if upos != tt.upos || feats != tt.feats {
+			t.Errorf("LookupTag(%q).UD(): want %s %s, got %s %s", tt.tag, tt.upos, tt.feats, upos, feats)
+		}
+	}
+}
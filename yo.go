@@ -0,0 +1,128 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// YoChange describes a word of the text considered by RestoreYo.
+type YoChange struct {
+	Offset      int      // byte offset of the word in the text
+	Word        string   // the word as written in the text
+	Replacement string   // the word with ё restored; empty if ambiguous
+	Ambiguous   bool     // the dictionary has the word both with е and ё
+	Variants    []string // the lowercase spellings found in the dictionary, if ambiguous
+}
+
+// RestoreYo replaces е with ё in the words of the text where the dictionary
+// makes it unambiguous (e.g. еж -> ёж), keeping the letter case.
+// The ambiguous words (e.g. все/всё, черт/чёрт) are left alone.
+// It returns the resulting text and the list of the changed and
+// the ambiguous words in the order of their occurrence.
+func RestoreYo(text string) (string, []YoChange) {
+	if probDAWG == nil {
+		panic("not initialized; call Init or InitWith")
+	}
+
+	var sb strings.Builder
+	var changes []YoChange
+	last := 0
+	// restore handles the word text[start:end] and reports
+	// whether it is in the dictionary
+	restore := func(start, end int) bool {
+		word := text[start:end]
+		lower := strings.ToLower(word)
+		variants := yoVariants(lower)
+		switch {
+		case len(variants) == 1 && variants[0] != lower:
+			repl := withYo(word, variants[0])
+			changes = append(changes, YoChange{Offset: start, Word: word, Replacement: repl})
+			sb.WriteString(text[last:start])
+			sb.WriteString(repl)
+			last = end
+		case len(variants) > 1:
+			changes = append(changes, YoChange{Offset: start, Word: word, Ambiguous: true, Variants: variants})
+		}
+		return len(variants) > 0
+	}
+
+	for _, tok := range Tokenize(text) {
+		if tok.Kind != TokenWord || !strings.ContainsAny(tok.Text, "еЕ") {
+			continue
+		}
+		if restore(tok.Start, tok.End) || strings.IndexFunc(tok.Text, isHyphen) < 0 {
+			continue
+		}
+		// the parts of a hyphenated word missing in the dictionary
+		restorePart := func(start, end int) {
+			if strings.ContainsAny(text[start:end], "еЕ") {
+				restore(start, end)
+			}
+		}
+		start := tok.Start
+		for i, r := range tok.Text {
+			if isHyphen(r) {
+				restorePart(start, tok.Start+i)
+				start = tok.Start + i + utf8.RuneLen(r)
+			}
+		}
+		restorePart(start, tok.End)
+	}
+	if last == 0 {
+		return text, changes
+	}
+	sb.WriteString(text[last:])
+	return sb.String(), changes
+}
+
+// yoVariants returns the distinct spellings of the lowercase word
+// found in the dictionary, with е possibly replaced with ё.
+func yoVariants(word string) []string {
+	words, _, _ := parse(word)
+	var variants []string
+	for _, w := range words {
+		found := false
+		for _, v := range variants {
+			found = found || v == w
+		}
+		if !found {
+			variants = append(variants, w)
+		}
+	}
+	return variants
+}
+
+// withYo returns the word with е replaced with ё (keeping the case)
+// in the positions where the lowercase spelling has ё.
+func withYo(word, spelling string) string {
+	rr := []rune(word)
+	i := 0
+	for _, r := range spelling {
+		if i == len(rr) {
+			break
+		}
+		if r == 'ё' {
+			switch rr[i] {
+			case 'е':
+				rr[i] = 'ё'
+			case 'Е':
+				rr[i] = 'Ё'
+			}
+		}
+		i++
+	}
+	return string(rr)
+}
@@ -0,0 +1,59 @@
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General
+// Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package morph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRestoreYo(t *testing.T) {
+	text := "Еж и черт — все это пришел."
+	got, changes := RestoreYo(text)
+	if want := "Ёж и черт — все это пришёл."; got != want {
+		t.Errorf("RestoreYo(%q): want %q, got %q", text, want, got)
+	}
+
+	var changed, ambiguous []string
+	for _, c := range changes {
+		if c.Ambiguous {
+			ambiguous = append(ambiguous, c.Word)
+		} else {
+			changed = append(changed, c.Replacement)
+		}
+	}
+	if want := []string{"Ёж", "пришёл"}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("RestoreYo: want changed %v, got %v", want, changed)
+	}
+	if want := []string{"черт", "все"}; !reflect.DeepEqual(ambiguous, want) {
+		t.Errorf("RestoreYo: want ambiguous %v, got %v", want, ambiguous)
+	}
+	if changes[0].Offset != 0 || changes[1].Offset != len("Еж и ") {
+		t.Errorf("RestoreYo: wrong offsets in %v", changes)
+	}
+
+	// the parts of a hyphenated word, with the hyphen U+2010 taking 3 bytes
+	text = "еж‐пришел и еж"
+	got, changes = RestoreYo(text)
+	if want := "ёж‐пришёл и ёж"; got != want {
+		t.Errorf("RestoreYo(%q): want %q, got %q", text, want, got)
+	}
+	var offsets []int
+	for _, c := range changes {
+		offsets = append(offsets, c.Offset)
+	}
+	if want := []int{0, len("еж‐"), len("еж‐пришел и ")}; !reflect.DeepEqual(offsets, want) {
+		t.Errorf("RestoreYo(%q): want offsets %v, got %v", text, want, offsets)
+	}
+}